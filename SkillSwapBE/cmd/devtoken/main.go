@@ -0,0 +1,54 @@
+// Command devtoken mints access tokens for a server running with AUTH_MODE=dev.
+//
+//	go run ./cmd/devtoken -sub "dev|alice" -email alice@example.com -name "Alice"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"skillswap/internal/devauth"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	sub := flag.String("sub", "dev|user1", "subject (user identifier) claim")
+	email := flag.String("email", "", "email claim")
+	name := flag.String("name", "", "name claim")
+	roles := flag.String("roles", "", "comma-separated roles to grant, e.g. moderator")
+	ttl := flag.Duration("ttl", devauth.DefaultTTL, "token lifetime")
+	flag.Parse()
+
+	// Pick up DEV_AUTH_KEY_FILE and AUTH0_AUDIENCE the same way the server does
+	godotenv.Load(".env")
+
+	keyFile := os.Getenv("DEV_AUTH_KEY_FILE")
+	if keyFile == "" {
+		keyFile = devauth.DefaultKeyFile
+	}
+
+	audience := os.Getenv("AUTH0_AUDIENCE")
+	if audience == "" {
+		audience = "skillswapapi"
+	}
+
+	issuer, err := devauth.LoadIssuer(keyFile, devauth.DefaultIssuer, audience)
+	if err != nil {
+		log.Fatalf("Failed to load dev issuer: %v", err)
+	}
+
+	var grantedRoles []string
+	if *roles != "" {
+		grantedRoles = strings.Split(*roles, ",")
+	}
+
+	token, err := issuer.Mint(*sub, *email, *name, *ttl, grantedRoles...)
+	if err != nil {
+		log.Fatalf("Failed to mint token: %v", err)
+	}
+
+	fmt.Println(token)
+}
@@ -1,37 +1,104 @@
 package main
 
 import (
+	"context"
 	"log"
-	"net/http"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
 	"skillswap/internal/database"
-	"skillswap/internal/handlers"
+	"skillswap/internal/devauth"
+	"skillswap/internal/logging"
+	"skillswap/internal/media"
+	"skillswap/internal/metrics"
 	"skillswap/internal/middleware"
+	"skillswap/internal/moderation"
+	"skillswap/internal/openapi"
+	"skillswap/internal/ratelimit"
+	"skillswap/internal/server"
+	"skillswap/internal/services"
+	"skillswap/internal/tracing"
+	"skillswap/internal/version"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	// SIGTERM (a Heroku dyno restart) or Ctrl-C starts a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
 	// Load .env file in development
+	var envErr error
 	if os.Getenv("GO_ENV") != "production" {
-		if err := godotenv.Load(".env"); err != nil {
-			log.Println("No .env file found, using environment variables")
-		}
+		envErr = godotenv.Load(".env")
+	}
+
+	// Structured logging: LOG_LEVEL is debug, info, warn or error, LOG_FORMAT json or text
+	if err := logging.Setup(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	if envErr != nil {
+		slog.Info("No .env file found, using environment variables")
+	}
+
+	// Tracing: OTEL_TRACES_EXPORTER is otlp, stdout or none
+	shutdownTracing, err := tracing.Setup(context.Background(), os.Getenv("OTEL_TRACES_EXPORTER"))
+	if err != nil {
+		fatal("Failed to set up tracing", err)
 	}
 
 	// Initialize database connection
 	if err := database.Connect(); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
+	}
+
+	// Trace every query run with a request context
+	if err := tracing.InstrumentDB(database.GetDB()); err != nil {
+		fatal("Failed to instrument database for tracing", err)
+	}
+
+	// Expose connection pool stats on /metrics
+	if sqlDB, err := database.GetDB().DB(); err == nil {
+		if err := metrics.RegisterDBStats(sqlDB); err != nil {
+			slog.Warn("Could not register database pool metrics", "error", err)
+		}
 	}
-	defer database.Close()
 
 	// Run database migrations
 	if err := database.Migrate(); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+		fatal("Failed to migrate database", err)
 	}
 
+	// Background workers stop when ctx is cancelled and are waited for on shutdown
+	var workers sync.WaitGroup
+
+	// Anonymise accounts whose deletion grace period has ended
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		services.NewAccountService().PurgeEvery(ctx, time.Hour)
+	}()
+
+	// Pre-screen free text against the configured word list and regex rules
+	var moderationRules []byte
+	if rulesFile := os.Getenv("MODERATION_RULES_FILE"); rulesFile != "" {
+		var err error
+		if moderationRules, err = os.ReadFile(rulesFile); err != nil {
+			fatal("Failed to read moderation rules", err)
+		}
+	}
+	screener, err := moderation.FromConfig(os.Getenv("MODERATION_BLOCKED_WORDS"), string(moderationRules))
+	if err != nil {
+		fatal("Failed to load moderation rules", err)
+	}
+	moderation.SetScreener(screener)
+
 	// Auth0 configuration
 	domain := os.Getenv("AUTH0_DOMAIN")
 	if domain == "" {
@@ -43,35 +110,110 @@ func main() {
 		audience = "skillswapapi" // Default API identifier
 	}
 
-	router := mux.NewRouter()
+	// Trusted identity providers: Auth0 plus any configured in AUTH_PROVIDERS
+	providers := []middleware.IdentityProvider{middleware.Auth0Provider(domain, audience)}
+	if raw := os.Getenv("AUTH_PROVIDERS"); raw != "" {
+		extra, err := middleware.ParseProviders(raw)
+		if err != nil {
+			fatal("Failed to load identity providers", err)
+		}
+		providers = append(providers, extra...)
+	}
 
-	// Health check endpoint
-	router.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+	// Dev auth mode adds a built-in issuer that signs tokens locally
+	var devIssuer *devauth.Issuer
+	if os.Getenv("AUTH_MODE") == "dev" {
+		keyFile := os.Getenv("DEV_AUTH_KEY_FILE")
+		if keyFile == "" {
+			keyFile = devauth.DefaultKeyFile
+		}
 
-	// API routes
-	api := router.PathPrefix("/api/v1").Subrouter()
+		issuer, err := devauth.LoadIssuer(keyFile, devauth.DefaultIssuer, audience)
+		if err != nil {
+			fatal("Failed to enable dev auth mode", err)
+		}
+		devIssuer = issuer
+		providers = append(providers, middleware.IdentityProvider{
+			Name:       "dev",
+			Issuer:     issuer.Issuer(),
+			Audience:   issuer.Audience(),
+			RolesClaim: devauth.RolesClaim,
+			KeyFunc:    issuer.KeyFunc,
+		})
+	}
 
-	// Public routes (no authentication required)
-	public := api.PathPrefix("/public").Subrouter()
-	public.HandleFunc("/skills", handlers.GetSkills).Methods("GET")
-	public.HandleFunc("/skills/{id}", handlers.GetSkillByID).Methods("GET")
-	public.HandleFunc("/skills/search", handlers.SearchSkills).Methods("GET")
-	public.HandleFunc("/users", handlers.GetUsers).Methods("GET")
-	public.HandleFunc("/users/{id}", handlers.GetUserByID).Methods("GET")
-	public.HandleFunc("/users/{id}/skills", handlers.GetUserSkills).Methods("GET")
+	tokenValidator, err := middleware.NewTokenValidator(providers...)
+	if err != nil {
+		fatal("Failed to set up token validation", err)
+	}
 
-	// Protected routes (authentication required)
-	protected := api.PathPrefix("/protected").Subrouter()
-	protected.Use(middleware.EnsureValidToken(domain, audience))
-	protected.Use(middleware.EnsureUserExists()) // Automatically create users if they don't exist
-	protected.HandleFunc("/dashboard", handlers.GetUserDashboard).Methods("GET")
-	protected.HandleFunc("/profile", handlers.GetUserProfile).Methods("GET")
-	protected.HandleFunc("/profile", handlers.UpdateUserProfile).Methods("PUT")
-	protected.HandleFunc("/profile/{id}", handlers.GetUserProfile).Methods("GET")
-	protected.HandleFunc("/my-skills", handlers.GetMySkills).Methods("GET")
+	// Warm the JWKS cache so the first requests and readiness probes don't wait on it
+	go func() {
+		for _, name := range tokenValidator.ProviderNames() {
+			if err := tokenValidator.CheckSigningKeys(ctx, name); err != nil {
+				slog.Warn("Could not load identity provider signing keys", "provider", name, "error", err)
+			}
+		}
+	}()
 
-	// Apply middleware
-	router.Use(middleware.LoggingMiddleware)
+	// Rate limits: RATE_LIMIT_BACKEND is memory (per instance) or postgres (shared)
+	middleware.TrustForwardedFor(os.Getenv("TRUST_PROXY") == "true")
+	rateLimits, err := loadRateLimits()
+	if err != nil {
+		fatal("Failed to load rate limits", err)
+	}
+	var rateLimitStore ratelimit.Store
+	switch backend := os.Getenv("RATE_LIMIT_BACKEND"); backend {
+	case "", "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		postgresStore := ratelimit.NewPostgresStore(database.GetDB())
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			postgresStore.PruneEvery(ctx, 10*time.Minute)
+		}()
+		rateLimitStore = postgresStore
+	default:
+		fatal("Failed to set up rate limiting", fmt.Errorf("unknown backend %q, expected memory or postgres", backend))
+	}
+
+	// Uploaded media: MEDIA_STORAGE is local (MEDIA_DIR on disk) or s3
+	switch backend := os.Getenv("MEDIA_STORAGE"); backend {
+	case "", "local":
+		if dir := os.Getenv("MEDIA_DIR"); dir != "" {
+			media.SetStorage(media.NewLocalStorage(dir))
+		}
+	case "s3":
+		s3Storage, err := media.NewS3Storage(media.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			fatal("Failed to set up media storage", err)
+		}
+		media.SetStorage(s3Storage)
+	default:
+		fatal("Failed to set up media storage", fmt.Errorf("unknown storage %q, expected local or s3", backend))
+	}
+	media.SetBaseURL(os.Getenv("MEDIA_BASE_URL"))
+
+	router := server.NewRouter(tokenValidator, middleware.NewRateLimiter(rateLimitStore, rateLimits))
+
+	if devIssuer != nil {
+		dev := router.PathPrefix("/dev").Subrouter()
+		dev.HandleFunc("/.well-known/jwks.json", devIssuer.JWKSHandler).Methods("GET")
+		dev.HandleFunc("/token", devIssuer.TokenHandler).Methods("POST")
+	}
+
+	// Swagger UI for the OpenAPI spec, which is always served at /openapi.yaml
+	if os.Getenv("GO_ENV") != "production" {
+		router.HandleFunc("/docs", openapi.DocsHandler).Methods("GET")
+	}
 
 	// Setup CORS - get allowed origins from environment or use defaults
 	allowedOrigins := []string{
@@ -93,9 +235,11 @@ func main() {
 	c := cors.New(cors.Options{
 		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type", "Accept", "Origin", "X-Requested-With"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "Accept", "Origin", "X-Requested-With", middleware.RequestIDHeader, "traceparent", "tracestate"},
+		ExposedHeaders:   []string{middleware.RequestIDHeader, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
-		Debug:            true, // Enable CORS debugging
+		Debug:            slog.Default().Enabled(context.Background(), slog.LevelDebug), // CORS debugging at LOG_LEVEL=debug
+		Logger:           slog.NewLogLogger(slog.Default().Handler(), slog.LevelDebug),
 	})
 
 	handler := c.Handler(router)
@@ -106,14 +250,66 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("SkillSwap Backend server starting on port %s", port)
-	log.Printf("Health check available at: http://localhost:%s/health", port)
-	log.Printf("Public API base URL: http://localhost:%s/api/v1/public", port)
-	log.Printf("Protected API base URL: http://localhost:%s/api/v1/protected", port)
-	log.Printf("Auth0 Domain: %s", domain)
-	log.Printf("Auth0 Audience: %s", audience)
+	slog.Info("SkillSwap Backend server starting",
+		"port", port,
+		"version", version.Version,
+		"commit", version.Commit,
+		"health_url", "http://localhost:"+port+"/health",
+		"readiness_url", "http://localhost:"+port+"/health/ready",
+		"public_api_url", "http://localhost:"+port+"/api/v1/public",
+		"protected_api_url", "http://localhost:"+port+"/api/v1/protected",
+	)
+	for _, provider := range providers {
+		slog.Info("Trusted identity provider", "name", provider.Name, "issuer", provider.Issuer, "audience", provider.Audience)
+	}
+	if devIssuer != nil {
+		slog.Warn("DEV AUTH MODE: tokens are signed locally, mint one with POST /dev/token", "token_url", "http://localhost:"+port+"/dev/token")
+	}
+
+	err = server.Run(ctx, server.NewHTTPServer(":"+port, handler), server.DrainTimeout)
+	if err != nil {
+		slog.Error("Server stopped with an error", "error", err)
+	}
+
+	// Stop background workers and let them finish their current run before
+	// flushing traces and closing the database pool
+	stop()
+	workers.Wait()
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Warn("Could not flush traces", "error", err)
+	}
+	if err := database.Close(); err != nil {
+		slog.Warn("Could not close database pool", "error", err)
+	}
+
+	slog.Info("Server stopped")
+	if err != nil {
+		os.Exit(1)
+	}
+}
 
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
-		log.Fatal("Server failed to start:", err)
+// loadRateLimits applies RATE_LIMIT_PUBLIC, RATE_LIMIT_PROTECTED and
+// RATE_LIMIT_WRITES, each written like "60/1m" or "off", over the defaults
+func loadRateLimits() (middleware.RateLimits, error) {
+	limits := middleware.DefaultRateLimits
+	for env, limit := range map[string]*ratelimit.Limit{
+		"RATE_LIMIT_PUBLIC":    &limits.Public,
+		"RATE_LIMIT_PROTECTED": &limits.Protected,
+		"RATE_LIMIT_WRITES":    &limits.Writes,
+	} {
+		if raw := os.Getenv(env); raw != "" {
+			parsed, err := ratelimit.ParseLimit(raw)
+			if err != nil {
+				return limits, fmt.Errorf("%s: %w", env, err)
+			}
+			*limit = parsed
+		}
 	}
+	return limits, nil
+}
+
+// fatal logs an error that stops the server and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
\ No newline at end of file
@@ -0,0 +1,100 @@
+// Package apierror writes API errors in one JSON format:
+//
+//	{"error": {"code": "not_found", "message": "User not found", "request_id": "..."}}
+//
+// Validation failures add a "fields" list naming each invalid field.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"skillswap/internal/logging"
+)
+
+// Error codes for statuses that don't need anything more specific
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeValidationFailed = "validation_failed"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "unavailable"
+)
+
+// FieldError describes one invalid field in a request body
+type FieldError struct {
+	Field   string `json:"field"` // JSON name of the field, e.g. "rating"
+	Rule    string `json:"rule"`  // Rule that failed, e.g. "required" or "max"
+	Message string `json:"message"`
+}
+
+// Error is the body of every error response
+type Error struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	Details   interface{}  `json:"details,omitempty"` // Extra context for specific codes
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Response wraps an Error under "error"
+type Response struct {
+	Error Error `json:"error"`
+}
+
+// Write sends message with the generic code for status
+func Write(w http.ResponseWriter, r *http.Request, status int, message string) {
+	WriteError(w, r, status, Error{Code: codeForStatus(status), Message: message})
+}
+
+// WriteCode sends message with a specific code, e.g. "account_suspended"
+func WriteCode(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	WriteError(w, r, status, Error{Code: code, Message: message})
+}
+
+// WriteValidation sends a 422 listing the invalid fields
+func WriteValidation(w http.ResponseWriter, r *http.Request, fields []FieldError) {
+	WriteError(w, r, http.StatusUnprocessableEntity, Error{
+		Code:    CodeValidationFailed,
+		Message: "The request has invalid fields",
+		Fields:  fields,
+	})
+}
+
+// WriteError sends apiErr, filling in the request ID
+func WriteError(w http.ResponseWriter, r *http.Request, status int, apiErr Error) {
+	apiErr.RequestID = logging.RequestID(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{Error: apiErr})
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,50 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"skillswap/internal/logging"
+)
+
+func TestWriteIncludesCodeAndRequestID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/protected/profile/missing", nil)
+	req = req.WithContext(logging.WithRequestID(req.Context(), "req-123"))
+	rr := httptest.NewRecorder()
+
+	Write(rr, req, http.StatusNotFound, "User not found")
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected a JSON response, got %s", ct)
+	}
+
+	var response Response
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	want := Error{Code: CodeNotFound, Message: "User not found", RequestID: "req-123"}
+	if response.Error.Code != want.Code || response.Error.Message != want.Message || response.Error.RequestID != want.RequestID {
+		t.Errorf("Expected %+v, got %+v", want, response.Error)
+	}
+}
+
+func TestWriteValidationListsFields(t *testing.T) {
+	rr := httptest.NewRecorder()
+	WriteValidation(rr, httptest.NewRequest("POST", "/", nil), []FieldError{
+		{Field: "rating", Rule: "max", Message: "must be at most 5"},
+	})
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+	var response Response
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Error.Code != CodeValidationFailed || len(response.Error.Fields) != 1 || response.Error.Fields[0].Field != "rating" {
+		t.Errorf("Expected a validation error for rating, got %+v", response.Error)
+	}
+}
@@ -0,0 +1,175 @@
+// Package audit writes the append-only audit log. The acting user, request ID
+// and client IP travel on the request context; Record picks them up from the
+// transaction it is given, so the entry commits or rolls back with the change.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"skillswap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Actor is who made a change, and from where
+type Actor struct {
+	UserID    string
+	RequestID string
+	IP        string
+}
+
+type actorKey struct{}
+
+// WithActor attaches the actor to a request context
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithUser sets the acting user, keeping any request details already attached
+func WithUser(ctx context.Context, userID string) context.Context {
+	actor := ActorFromContext(ctx)
+	actor.UserID = userID
+	return WithActor(ctx, actor)
+}
+
+// ActorFromContext returns the actor attached to ctx, or a zero Actor for
+// system actions
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{}
+	}
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// Change is the before and after value of one field
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// ignoredFields change on every write and would only add noise
+var ignoredFields = map[string]bool{"updated_at": true}
+
+// Diff compares the JSON form of two values field by field. Either side may be
+// nil, for creations and deletions.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for name, value := range beforeFields {
+		if !ignoredFields[name] && !reflect.DeepEqual(value, afterFields[name]) {
+			changes[name] = Change{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, seen := beforeFields[name]; !seen && !ignoredFields[name] && value != nil {
+			changes[name] = Change{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func fields(value interface{}) (map[string]interface{}, error) {
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil() {
+		return map[string]interface{}{}, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("audit values must be objects: %w", err)
+	}
+	return result, nil
+}
+
+// Record appends an audit entry using tx, which should be the transaction
+// making the change. before and after are diffed; pass nil for either side
+// of a creation or deletion, or for both to record the action alone.
+func Record(tx *gorm.DB, action, entityType, entityID string, before, after interface{}) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s %s: %w", entityType, entityID, err)
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	actor := ActorFromContext(tx.Statement.Context)
+	entry := models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changesJSON,
+		RequestID:  actor.RequestID,
+		IP:         actor.IP,
+	}
+	if actor.UserID != "" {
+		entry.ActorID = &actor.UserID
+	}
+
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Redacted replaces the values of personal fields in redacted entries
+const Redacted = "[redacted]"
+
+// personalFields are the diff fields that can hold someone's personal data,
+// including whole users embedded in another record
+var personalFields = []string{
+	"username", "full_name", "location", "bio", "avatar", "avatar_thumbnail", "email",
+	"subject", "claimed_name", "comment", "description", "notes", "student_notes",
+	"teacher_notes", "details", "held_content",
+	"user", "student", "teacher", "reviewer", "reviewee",
+}
+
+// Redact blanks the personal fields of every entry about userID, their
+// skills, reviews and bookings, and the IP of every entry they made. It is the
+// only change the audit_logs trigger allows, and only while
+// skillswap.audit_redaction is set, so call it in the transaction
+// anonymising the user and before their rows are deleted.
+func Redact(tx *gorm.DB, userID string) error {
+	if err := tx.Exec("SET LOCAL skillswap.audit_redaction = 'on'").Error; err != nil {
+		return err
+	}
+
+	marker, err := json.Marshal(Change{Before: Redacted, After: Redacted})
+	if err != nil {
+		return err
+	}
+	err = tx.Exec(`UPDATE audit_logs SET
+			changes = (
+				SELECT COALESCE(jsonb_object_agg(key, CASE WHEN key IN ? THEN ?::jsonb ELSE value END), '{}'::jsonb)
+				FROM jsonb_each(audit_logs.changes)
+			),
+			ip = CASE WHEN actor_id = @user THEN '' ELSE ip END
+		WHERE actor_id = @user
+			OR (entity_type = 'user' AND entity_id = @user)
+			OR (entity_type = 'skill' AND entity_id IN (SELECT id::text FROM skills WHERE user_id = @user))
+			OR (entity_type = 'review' AND entity_id IN (SELECT id::text FROM reviews WHERE reviewer_id = @user OR reviewee_id = @user))
+			OR (entity_type = 'booking' AND entity_id IN (SELECT id::text FROM bookings WHERE student_id = @user OR teacher_id = @user))`,
+		personalFields, string(marker), sql.Named("user", userID)).Error
+	if err != nil {
+		return fmt.Errorf("failed to redact audit log: %w", err)
+	}
+
+	return tx.Exec("SET LOCAL skillswap.audit_redaction = 'off'").Error
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type profile struct {
+		Name      string `json:"name"`
+		Bio       string `json:"bio"`
+		Points    int    `json:"points"`
+		UpdatedAt string `json:"updated_at"`
+	}
+
+	before := profile{Name: "Ann", Bio: "Hi", Points: 10, UpdatedAt: "yesterday"}
+	after := profile{Name: "Ann", Bio: "Hello", Points: 25, UpdatedAt: "today"}
+
+	changes, err := Diff(before, &after)
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %v", changes)
+	}
+	if changes["bio"].Before != "Hi" || changes["bio"].After != "Hello" {
+		t.Errorf("Unexpected bio change: %+v", changes["bio"])
+	}
+	if changes["points"].Before != float64(10) || changes["points"].After != float64(25) {
+		t.Errorf("Unexpected points change: %+v", changes["points"])
+	}
+
+	var none *profile
+	created, err := Diff(none, after)
+	if err != nil {
+		t.Fatalf("Diff returned error: %v", err)
+	}
+	if created["name"].After != "Ann" || created["name"].Before != nil {
+		t.Errorf("Expected creation to record the new name, got %+v", created["name"])
+	}
+}
+
+func TestWithUserKeepsRequestDetails(t *testing.T) {
+	ctx := WithActor(context.Background(), Actor{RequestID: "req-1", IP: "10.0.0.1"})
+	ctx = WithUser(ctx, "user-1")
+
+	actor := ActorFromContext(ctx)
+	if actor != (Actor{UserID: "user-1", RequestID: "req-1", IP: "10.0.0.1"}) {
+		t.Errorf("Unexpected actor: %+v", actor)
+	}
+}
@@ -0,0 +1,127 @@
+// Package badges awards badges, declared in Definitions, when a user's counts
+// reach a badge's target. Evaluate runs on the transaction of the booking or
+// review event that may have changed the counts, so an award commits with it.
+// Badges are kept once earned, even if a count later drops.
+package badges
+
+import (
+	"fmt"
+	"time"
+
+	"skillswap/internal/audit"
+	"skillswap/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Evaluate awards every badge userID now qualifies for and returns those that
+// are new. Badges already held are left alone, so it is safe to call on every
+// event.
+func Evaluate(tx *gorm.DB, userID string) ([]models.UserBadge, error) {
+	counts, err := metrics(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var awarded []models.UserBadge
+	now := time.Now()
+	for _, definition := range Definitions {
+		if counts[definition.Metric] < definition.Target {
+			continue
+		}
+
+		badge := models.UserBadge{UserID: userID, BadgeID: definition.ID, AwardedAt: now}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&badge)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to award badge %s: %w", definition.ID, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue // Already held
+		}
+		if err := audit.Record(tx, "badge.award", "user", userID, nil, badge); err != nil {
+			return nil, err
+		}
+		awarded = append(awarded, badge)
+	}
+	return awarded, nil
+}
+
+// Earned returns the badges userID holds, in Definitions order
+func Earned(db *gorm.DB, userID string) ([]models.EarnedBadge, error) {
+	held, err := held(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	earned := []models.EarnedBadge{}
+	for _, definition := range Definitions {
+		if badge, ok := held[definition.ID]; ok {
+			earned = append(earned, models.EarnedBadge{
+				ID:          definition.ID,
+				Name:        definition.Name,
+				Description: definition.Description,
+				AwardedAt:   badge.AwardedAt,
+			})
+		}
+	}
+	return earned, nil
+}
+
+// Progress returns how far userID is towards every badge, earned or not
+func Progress(db *gorm.DB, userID string) ([]models.BadgeProgress, error) {
+	held, err := held(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := metrics(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]models.BadgeProgress, 0, len(Definitions))
+	for _, definition := range Definitions {
+		entry := models.BadgeProgress{
+			ID:          definition.ID,
+			Name:        definition.Name,
+			Description: definition.Description,
+			Current:     min(counts[definition.Metric], definition.Target),
+			Target:      definition.Target,
+		}
+		if badge, ok := held[definition.ID]; ok {
+			entry.Current = definition.Target
+			entry.AwardedAt = &badge.AwardedAt
+		}
+		progress = append(progress, entry)
+	}
+	return progress, nil
+}
+
+// held returns the badges userID holds, by badge ID
+func held(db *gorm.DB, userID string) (map[string]models.UserBadge, error) {
+	var badges []models.UserBadge
+	if err := db.Where("user_id = ?", userID).Find(&badges).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.UserBadge, len(badges))
+	for _, badge := range badges {
+		byID[badge.BadgeID] = badge
+	}
+	return byID, nil
+}
+
+// metrics counts every metric a definition uses for userID
+func metrics(db *gorm.DB, userID string) (map[Metric]int, error) {
+	counts := make(map[Metric]int)
+	for _, definition := range Definitions {
+		if _, done := counts[definition.Metric]; done {
+			continue
+		}
+		var count int
+		if err := db.Raw(metricSQL[definition.Metric], userID).Scan(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", definition.Metric, err)
+		}
+		counts[definition.Metric] = count
+	}
+	return counts, nil
+}
@@ -0,0 +1,23 @@
+package badges
+
+import "testing"
+
+func TestDefinitionsAreValid(t *testing.T) {
+	seen := map[string]bool{}
+	for _, definition := range Definitions {
+		if definition.ID == "" || definition.Name == "" || definition.Description == "" {
+			t.Errorf("Badge %+v is missing an ID, name or description", definition)
+		}
+		if seen[definition.ID] {
+			t.Errorf("Badge ID %s is used twice", definition.ID)
+		}
+		seen[definition.ID] = true
+
+		if _, ok := metricSQL[definition.Metric]; !ok {
+			t.Errorf("Badge %s counts %q, which has no query", definition.ID, definition.Metric)
+		}
+		if definition.Target < 1 {
+			t.Errorf("Badge %s has target %d, which every user would meet", definition.ID, definition.Target)
+		}
+	}
+}
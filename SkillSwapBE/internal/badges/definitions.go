@@ -0,0 +1,44 @@
+package badges
+
+// Metric is a count kept for every user that badges set targets against
+type Metric string
+
+const (
+	LessonsTaught    Metric = "lessons_taught"    // Completed bookings as the teacher
+	FiveStarReviews  Metric = "five_star_reviews" // Visible five-star reviews received
+	CategoriesTaught Metric = "categories_taught" // Distinct skill categories of completed bookings
+	HoursTaught      Metric = "hours_taught"      // Whole hours of completed bookings, from the skill's duration
+)
+
+// Definition declares a badge, awarded once a user's Metric reaches Target
+type Definition struct {
+	ID          string // Stored on awards, so never change it
+	Name        string
+	Description string
+	Metric      Metric
+	Target      int
+}
+
+// Definitions lists every badge, in the order they are shown. A new badge is
+// awarded to users who already qualify on their next booking or review event.
+var Definitions = []Definition{
+	{ID: "first-lesson", Name: "First Lesson", Description: "Taught your first lesson", Metric: LessonsTaught, Target: 1},
+	{ID: "ten-lessons", Name: "Regular", Description: "Taught 10 lessons", Metric: LessonsTaught, Target: 10},
+	{ID: "five-star-ten", Name: "Crowd Favourite", Description: "Received 10 five-star reviews", Metric: FiveStarReviews, Target: 10},
+	{ID: "three-categories", Name: "All-Rounder", Description: "Taught lessons in 3 categories", Metric: CategoriesTaught, Target: 3},
+	{ID: "hundred-hours", Name: "Centurion", Description: "Taught 100 hours of lessons", Metric: HoursTaught, Target: 100},
+}
+
+// metricSQL counts each metric for one user, given as the only parameter
+var metricSQL = map[Metric]string{
+	LessonsTaught: `SELECT COUNT(*) FROM bookings
+		WHERE teacher_id = ? AND status = 'completed' AND deleted_at IS NULL`,
+	FiveStarReviews: `SELECT COUNT(*) FROM reviews
+		WHERE reviewee_id = ? AND rating = 5 AND is_public AND hidden_at IS NULL AND deleted_at IS NULL`,
+	CategoriesTaught: `SELECT COUNT(DISTINCT skills.category_id) FROM bookings
+		JOIN skills ON skills.id = bookings.skill_id
+		WHERE bookings.teacher_id = ? AND bookings.status = 'completed' AND bookings.deleted_at IS NULL`,
+	HoursTaught: `SELECT COALESCE(SUM(skills.duration), 0) / 60 FROM bookings
+		JOIN skills ON skills.id = bookings.skill_id
+		WHERE bookings.teacher_id = ? AND bookings.status = 'completed' AND bookings.deleted_at IS NULL`,
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLExpiresEntries(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := New[string, int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	if value, ok := c.Get("a"); !ok || value != 1 {
+		t.Fatalf("Expected 1, got %d (found %v)", value, ok)
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected the entry to expire after the TTL")
+	}
+
+	// Setting another key prunes the expired one
+	c.Set("b", 2)
+	if len(c.entries) != 1 {
+		t.Errorf("Expected expired entries to be pruned, got %d entries", len(c.entries))
+	}
+}
+
+func TestTTLDeleteAndClear(t *testing.T) {
+	c := New[string, int](time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected a to be deleted")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("Expected b to be kept")
+	}
+
+	c.Clear()
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected Clear to drop every entry")
+	}
+}
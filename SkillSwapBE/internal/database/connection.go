@@ -1,8 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"skillswap/internal/models"
 
@@ -59,6 +60,11 @@ func Connect() error {
 			host, user, password, dbname, port, sslmode)
 	}
 
+	return ConnectDSN(dsn)
+}
+
+// ConnectDSN initializes the database connection from an explicit DSN
+func ConnectDSN(dsn string) error {
 	// Configure GORM logger
 	logLevel := logger.Silent
 	if os.Getenv("DB_DEBUG") == "true" {
@@ -68,14 +74,15 @@ func Connect() error {
 	// Connect to database
 	var err error
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logLevel),
+		Logger:         logger.Default.LogMode(logLevel),
+		TranslateError: true, // Unique violations return gorm.ErrDuplicatedKey
 	})
 	
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("Successfully connected to PostgreSQL database")
+	slog.Info("Connected to PostgreSQL database")
 	return nil
 }
 
@@ -87,22 +94,38 @@ func Migrate() error {
 
 	// Enable UUID extension
 	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"").Error; err != nil {
-		log.Printf("Warning: Could not create uuid-ossp extension: %v", err)
+		slog.Warn("Could not create uuid-ossp extension", "error", err)
 	}
 
 	// Auto-migrate models
 	err := DB.AutoMigrate(
 		&models.User{},
+		&models.Category{},
+		&models.Tag{},
 		&models.Skill{},
 		&models.Booking{},
 		&models.Review{},
+		&models.UserIdentity{},
+		&models.Suspension{},
+		&models.Report{},
+		&models.AuditLog{},
+		&models.RateLimitBucket{},
+		&models.PointsEvent{},
+		&models.UserBadge{},
+		&models.UsernameChange{},
+		&models.SkillImage{},
 	)
 	
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	log.Println("Database migration completed successfully")
+	// Run versioned data migrations
+	if err := runMigrations(); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	slog.Info("Database migration completed")
 	return nil
 }
 
@@ -118,6 +141,18 @@ func Close() error {
 	return nil
 }
 
+// Ping checks that the database is reachable
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return fmt.Errorf("database connection not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
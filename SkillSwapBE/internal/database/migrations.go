@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"skillswap/internal/models"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration records a data migration that has been applied.
+// AutoMigrate keeps tables in step with the models; these migrations cover
+// the changes it can't make, such as moving data or dropping columns.
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+type migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// migrations run in order, each exactly once, after AutoMigrate
+var migrations = []migration{
+	{Version: 1, Name: "move auth0_id into user_identities", Up: migrateAuth0Identities},
+	{Version: 2, Name: "make audit_logs append-only", Up: protectAuditLogs},
+	{Version: 3, Name: "open points history with current balances", Up: openPointsHistory},
+	{Version: 4, Name: "make usernames unique ignoring case", Up: uniqueUsernamesIgnoringCase},
+	{Version: 5, Name: "file skills under managed categories", Up: fileSkillsUnderCategories},
+	{Version: 6, Name: "move skill tags into the tags table", Up: moveSkillTags},
+	{Version: 7, Name: "let audit entries be redacted", Up: allowAuditRedaction},
+}
+
+// runMigrations applies any migrations newer than the recorded schema version
+func runMigrations() error {
+	if err := DB.AutoMigrate(&SchemaMigration{}); err != nil {
+		return err
+	}
+
+	current, err := SchemaVersion(context.Background())
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		slog.Info("Applied migration", "version", m.Version, "name", m.Name)
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the latest applied migration version
+func SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := DB.WithContext(ctx).Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// LatestSchemaVersion is the version this build migrates the database to
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// migrateAuth0Identities copies users.auth0_id into user_identities under the
+// "auth0" provider, then drops the column so users are no longer tied to Auth0
+func migrateAuth0Identities(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn("users", "auth0_id") {
+		return nil
+	}
+
+	err := tx.Exec(`INSERT INTO user_identities (user_id, provider, subject, created_at, updated_at)
+		SELECT id, 'auth0', auth0_id, NOW(), NOW() FROM users WHERE auth0_id IS NOT NULL
+		ON CONFLICT DO NOTHING`).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Migrator().DropColumn("users", "auth0_id")
+}
+
+// protectAuditLogs adds a trigger rejecting any update or delete of audit
+// entries, so the log can't be rewritten even by code with full table access
+func protectAuditLogs(tx *gorm.DB) error {
+	err := tx.Exec(`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql`).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(`CREATE TRIGGER audit_logs_append_only
+		BEFORE UPDATE OR DELETE ON audit_logs
+		FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`).Error
+}
+
+// allowAuditRedaction lets a transaction that has set
+// skillswap.audit_redaction blank the changes and IP of audit entries, so an
+// anonymised user's personal data can be removed from the log. Everything
+// else about an entry stays fixed, and entries still can't be deleted.
+func allowAuditRedaction(tx *gorm.DB) error {
+	return tx.Exec(`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP = 'UPDATE' AND current_setting('skillswap.audit_redaction', true) = 'on'
+				AND (NEW.id, NEW.actor_id, NEW.action, NEW.entity_type, NEW.entity_id, NEW.request_id, NEW.created_at)
+					IS NOT DISTINCT FROM (OLD.id, OLD.actor_id, OLD.action, OLD.entity_type, OLD.entity_id, OLD.request_id, OLD.created_at) THEN
+				RETURN NEW;
+			END IF;
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql`).Error
+}
+
+// openPointsHistory records each user's points from before the history
+// existed as one event, so the all-time total matches the sum of the history.
+// The event is dated when the user joined; it can't be split into the windows
+// the points were actually earned in.
+func openPointsHistory(tx *gorm.DB) error {
+	return tx.Exec(`INSERT INTO points_events (user_id, points, reason, created_at)
+		SELECT id, points, 'balance.opening', created_at FROM users WHERE points <> 0`).Error
+}
+
+// uniqueUsernamesIgnoringCase renames all but the oldest of any usernames that
+// differ only in case, by appending part of the user's ID, then indexes
+// LOWER(username) so "Sam" and "sam" can't both be taken again
+func uniqueUsernamesIgnoringCase(tx *gorm.DB) error {
+	err := tx.Exec(`UPDATE users SET username = username || '_' || SUBSTR(REPLACE(id::text, '-', ''), 1, 6)
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY LOWER(username) ORDER BY created_at, id) AS n FROM users
+			) ranked WHERE n > 1
+		)`).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec(`CREATE UNIQUE INDEX idx_users_username_lower ON users (LOWER(username))`).Error
+}
+
+// fileSkillsUnderCategories creates a top-level category for each distinct
+// skill category, merging spellings that share a slug such as "Music" and
+// "music ", and files every skill under its category. The most used spelling
+// names the category. Categories without a letter or digit are left unfiled.
+func fileSkillsUnderCategories(tx *gorm.DB) error {
+	var spellings []struct {
+		Category string
+		Count    int64
+	}
+	err := tx.Raw(`SELECT category, COUNT(*) AS count FROM skills WHERE category_id IS NULL
+		GROUP BY category ORDER BY count DESC, category`).Scan(&spellings).Error
+	if err != nil {
+		return err
+	}
+
+	for _, spelling := range spellings {
+		name := models.NormalizeCategoryName(spelling.Category)
+		slug := models.CategorySlug(name)
+		if slug == "" {
+			continue
+		}
+		err := tx.Exec(`INSERT INTO categories (name, slug, created_at, updated_at) VALUES (?, ?, NOW(), NOW())
+			ON CONFLICT (slug) DO NOTHING`, name, slug).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`UPDATE skills SET category_id = categories.id, category = categories.name FROM categories
+			WHERE categories.slug = ? AND skills.category = ? AND skills.category_id IS NULL`, slug, spelling.Category).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveSkillTags parses the old skills.tags text column into the tags table
+// and skill_tags join, normalizing each tag, then drops the column
+func moveSkillTags(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn("skills", "tags") {
+		return nil
+	}
+
+	var skills []struct {
+		ID   string
+		Tags string
+	}
+	if err := tx.Raw(`SELECT id, tags FROM skills WHERE TRIM(COALESCE(tags, '')) <> ''`).Scan(&skills).Error; err != nil {
+		return err
+	}
+
+	for _, skill := range skills {
+		for _, name := range models.NormalizeTags(parseTags(skill.Tags)) {
+			if err := tx.Exec(`INSERT INTO tags (name, created_at) VALUES (?, NOW()) ON CONFLICT (name) DO NOTHING`, name).Error; err != nil {
+				return err
+			}
+			err := tx.Exec(`INSERT INTO skill_tags (skill_id, tag_id) SELECT ?, id FROM tags WHERE name = ?
+				ON CONFLICT DO NOTHING`, skill.ID, name).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Migrator().DropColumn("skills", "tags")
+}
+
+// parseTags reads an old tags value. It should be a JSON array of strings,
+// but nothing checked, so anything else is split on commas.
+func parseTags(raw string) []string {
+	var tags []string
+	if err := json.Unmarshal([]byte(raw), &tags); err == nil {
+		return tags
+	}
+	return strings.FieldsFunc(raw, func(r rune) bool {
+		return strings.ContainsRune(`,;[]"`, r)
+	})
+}
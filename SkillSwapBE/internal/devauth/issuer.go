@@ -0,0 +1,208 @@
+// Package devauth is a built-in RS256 token issuer that stands in for Auth0
+// during offline development and in CI. It must never run in production.
+package devauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"skillswap/internal/apierror"
+	"time"
+
+	jose "gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+const (
+	// DefaultIssuer is the "iss" claim minted and expected in dev auth mode
+	DefaultIssuer = "https://skillswap.dev.local/"
+	// DefaultKeyFile is where the signing key is kept so the server and the
+	// devtoken CLI agree on it across restarts
+	DefaultKeyFile = ".dev-auth-key.pem"
+	// DefaultTTL is how long minted tokens stay valid
+	DefaultTTL = 24 * time.Hour
+	// RolesClaim carries the SkillSwap roles granted to a minted token
+	RolesClaim = "roles"
+)
+
+// ErrProduction is returned when dev auth is requested with GO_ENV=production
+var ErrProduction = errors.New("dev auth mode cannot be enabled when GO_ENV=production")
+
+// Issuer signs access tokens with a local RSA key
+type Issuer struct {
+	issuer   string
+	audience string
+	key      *rsa.PrivateKey
+	keyID    string
+	signer   jose.Signer
+}
+
+// LoadIssuer reads the signing key from keyFile, generating and saving a new
+// one if the file doesn't exist yet
+func LoadIssuer(keyFile, issuer, audience string) (*Issuer, error) {
+	if os.Getenv("GO_ENV") == "production" {
+		return nil, ErrProduction
+	}
+
+	key, err := readKey(keyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		if err := writeKey(keyFile, key); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return NewIssuer(key, issuer, audience)
+}
+
+// NewIssuer wraps an existing key, e.g. one generated for a single test
+func NewIssuer(key *rsa.PrivateKey, issuer, audience string) (*Issuer, error) {
+	jwk := jose.JSONWebKey{Key: &key.PublicKey, Algorithm: string(jose.RS256), Use: "sig"}
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key ID: %w", err)
+	}
+	keyID := base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: keyID}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signer: %w", err)
+	}
+
+	return &Issuer{issuer: issuer, audience: audience, key: key, keyID: keyID, signer: signer}, nil
+}
+
+// Issuer returns the "iss" claim this issuer signs
+func (i *Issuer) Issuer() string {
+	return i.issuer
+}
+
+// Audience returns the "aud" claim this issuer signs
+func (i *Issuer) Audience() string {
+	return i.audience
+}
+
+// KeyFunc supplies the verification key to the JWT validator
+func (i *Issuer) KeyFunc(ctx context.Context) (interface{}, error) {
+	return &i.key.PublicKey, nil
+}
+
+// JWKS returns the public half of the signing key as a key set
+func (i *Issuer) JWKS() jose.JSONWebKeySet {
+	return jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       &i.key.PublicKey,
+		KeyID:     i.keyID,
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}}
+}
+
+// Mint signs a token for arbitrary identity claims, optionally granting roles
+func (i *Issuer) Mint(sub, email, name string, ttl time.Duration, roles ...string) (string, error) {
+	if sub == "" {
+		return "", errors.New("sub is required")
+	}
+
+	claims := map[string]interface{}{"email": email, "name": name}
+	if len(roles) > 0 {
+		claims[RolesClaim] = roles
+	}
+
+	now := time.Now()
+	return jwt.Signed(i.signer).
+		Claims(jwt.Claims{
+			Issuer:   i.issuer,
+			Subject:  sub,
+			Audience: jwt.Audience{i.audience},
+			IssuedAt: jwt.NewNumericDate(now),
+			Expiry:   jwt.NewNumericDate(now.Add(ttl)),
+		}).
+		Claims(claims).
+		CompactSerialize()
+}
+
+// JWKSHandler serves the public key set, like Auth0's /.well-known/jwks.json
+func (i *Issuer) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(i.JWKS())
+}
+
+// TokenRequest is the body accepted by TokenHandler
+type TokenRequest struct {
+	Sub   string   `json:"sub"`
+	Email string   `json:"email"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+// TokenResponse mirrors the shape of an OAuth token response
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// TokenHandler mints a token for the claims posted in the request body
+func (i *Issuer) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	var req TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	token, err := i.Mint(req.Sub, req.Email, req.Name, DefaultTTL, req.Roles...)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(DefaultTTL.Seconds()),
+	})
+}
+
+func readKey(keyFile string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", keyFile)
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", keyFile, err)
+	}
+	return key, nil
+}
+
+func writeKey(keyFile string, key *rsa.PrivateKey) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to save signing key: %w", err)
+	}
+	return nil
+}
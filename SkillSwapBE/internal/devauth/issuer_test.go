@@ -0,0 +1,86 @@
+package devauth_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"skillswap/internal/devauth"
+	"skillswap/internal/middleware"
+)
+
+func TestLoadIssuerRefusesProduction(t *testing.T) {
+	t.Setenv("GO_ENV", "production")
+
+	_, err := devauth.LoadIssuer(filepath.Join(t.TempDir(), "key.pem"), devauth.DefaultIssuer, "skillswapapi")
+	if !errors.Is(err, devauth.ErrProduction) {
+		t.Errorf("Expected ErrProduction, got %v", err)
+	}
+}
+
+func TestLoadIssuerReusesSavedKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+
+	first, err := devauth.LoadIssuer(keyFile, devauth.DefaultIssuer, "skillswapapi")
+	if err != nil {
+		t.Fatalf("LoadIssuer returned error: %v", err)
+	}
+	second, err := devauth.LoadIssuer(keyFile, devauth.DefaultIssuer, "skillswapapi")
+	if err != nil {
+		t.Fatalf("LoadIssuer returned error: %v", err)
+	}
+
+	if first.JWKS().Keys[0].KeyID != second.JWKS().Keys[0].KeyID {
+		t.Error("Expected the saved key to be reused")
+	}
+}
+
+func TestMintedTokenPassesValidation(t *testing.T) {
+	issuer, err := devauth.LoadIssuer(filepath.Join(t.TempDir(), "key.pem"), devauth.DefaultIssuer, "skillswapapi")
+	if err != nil {
+		t.Fatalf("LoadIssuer returned error: %v", err)
+	}
+
+	// Mint through the HTTP endpoint
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/dev/token", strings.NewReader(`{"sub": "dev|alice", "email": "alice@example.com", "name": "Alice"}`))
+	issuer.TokenHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var token devauth.TokenResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &token); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+
+	var claims *middleware.CustomClaims
+	tokenValidator, err := middleware.NewTokenValidator(middleware.IdentityProvider{
+		Name:     "dev",
+		Issuer:   issuer.Issuer(),
+		Audience: issuer.Audience(),
+		KeyFunc:  issuer.KeyFunc,
+	})
+	if err != nil {
+		t.Fatalf("NewTokenValidator returned error: %v", err)
+	}
+	handler := tokenValidator.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = middleware.GetUserFromContext(r.Context())
+	}))
+
+	req = httptest.NewRequest("GET", "/api/v1/protected/dashboard", nil)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected minted token to validate, got status %d", rr.Code)
+	}
+	if claims == nil || claims.Provider != "dev" || claims.Sub != "dev|alice" || claims.Email != "alice@example.com" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/middleware"
+	"skillswap/internal/models"
+	"skillswap/internal/services"
+	"time"
+)
+
+// ExportAccount downloads everything stored about the current user as JSON
+func ExportAccount(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	accountService := services.NewAccountService()
+	export, err := accountService.ExportUserData(r.Context(), user.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to export account data")
+		return
+	}
+
+	// Email isn't stored, it comes from the token
+	if claims, err := middleware.GetUserFromContext(r.Context()); err == nil {
+		export.Profile.Email = claims.Email
+	}
+
+	filename := fmt.Sprintf("skillswap-export-%s.json", export.ExportedAt.Format("20060102"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	json.NewEncoder(w).Encode(export)
+}
+
+// ScheduleAccountDeletion starts the grace period before the current user's
+// account is anonymised
+func ScheduleAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	accountService := services.NewAccountService()
+	scheduledAt, err := accountService.ScheduleDeletion(r.Context(), user.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to schedule account deletion")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(models.AccountDeletionResponse{
+		DeletionScheduledAt: scheduledAt,
+		Message:             "Your account will be deleted on " + scheduledAt.UTC().Format(time.RFC1123) + " unless you cancel before then",
+	})
+}
+
+// CancelAccountDeletion keeps the current user's account
+func CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	accountService := services.NewAccountService()
+	err := accountService.CancelDeletion(r.Context(), user.ID)
+	if errors.Is(err, services.ErrNoDeletionScheduled) {
+		apierror.Write(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to cancel account deletion")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/database"
+	"skillswap/internal/middleware"
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"skillswap/internal/repository"
+	"skillswap/internal/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	role := models.UserRole(query.Get("role"))
+	if role != "" && !role.IsValid() {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid role")
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	userRepo := repository.NewUserRepository(database.GetDB().WithContext(r.Context()))
+	users, err := userRepo.ListUsers(role, query.Get("query"), page)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pagination.Map(users, models.User.Account))
+}
+
+func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "User not found")
+	if !ok {
+		return
+	}
+	var roleReq models.UpdateUserRoleRequest
+	if !decodeJSON(w, r, &roleReq) {
+		return
+	}
+
+	userRepo := repository.NewUserRepository(database.GetDB().WithContext(r.Context()))
+	err := userRepo.UpdateUserRole(userID, roleReq.Role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+	services.ForgetUser(userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func SuspendUser(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+	userID, ok := pathID(w, r, "id", "User not found")
+	if !ok {
+		return
+	}
+
+	var suspendReq models.CreateSuspensionRequest
+	if !decodeJSON(w, r, &suspendReq) {
+		return
+	}
+	if suspendReq.ExpiresAt != nil && !suspendReq.ExpiresAt.After(time.Now()) {
+		apierror.Write(w, r, http.StatusBadRequest, "Expiry must be in the future")
+		return
+	}
+
+	if userID == moderator.ID {
+		apierror.Write(w, r, http.StatusBadRequest, "You cannot suspend yourself")
+		return
+	}
+
+	userService := services.NewUserService()
+	target, err := userService.GetUserByID(r.Context(), userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to suspend user")
+		return
+	}
+	if target.Role.Includes(models.RoleModerator) && !middleware.EffectiveRole(r).Includes(models.RoleAdmin) {
+		apierror.Write(w, r, http.StatusForbidden, "Only admins can suspend moderators")
+		return
+	}
+
+	suspension, err := userService.SuspendUser(r.Context(), userID, moderator.ID, suspendReq.Reason, suspendReq.ExpiresAt)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to suspend user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(suspension)
+}
+
+func LiftSuspension(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+	userID, ok := pathID(w, r, "id", "No active suspension")
+	if !ok {
+		return
+	}
+
+	userService := services.NewUserService()
+	err := userService.LiftSuspension(r.Context(), userID, moderator.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "No active suspension")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to lift suspension")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func GetUserSuspensions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "User not found")
+	if !ok {
+		return
+	}
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	suspensionRepo := repository.NewSuspensionRepository(database.GetDB().WithContext(r.Context()))
+	suspensions, err := suspensionRepo.ListSuspensionsByUser(userID, page)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get suspensions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suspensions)
+}
+
+func RemoveSkill(w http.ResponseWriter, r *http.Request) {
+	skillID, ok := pathID(w, r, "id", "Skill not found")
+	if !ok {
+		return
+	}
+
+	skillRepo := repository.NewSkillRepository(database.GetDB().WithContext(r.Context()))
+	err := skillRepo.DeleteSkill(skillID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "Skill not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to remove skill")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func HideReview(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+	reviewID, ok := pathID(w, r, "id", "Review not found")
+	if !ok {
+		return
+	}
+
+	reviewRepo := repository.NewReviewRepository(database.GetDB().WithContext(r.Context()))
+	review, err := reviewRepo.HideReview(reviewID, moderator.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "Review not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to hide review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+func ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := models.AuditLogFilter{
+		ActorID:    query.Get("actor_id"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		Action:     query.Get("action"),
+	}
+	for param, bound := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				apierror.Write(w, r, http.StatusBadRequest, "Invalid "+param+" time, expected RFC 3339")
+				return
+			}
+			*bound = &parsed
+		}
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	auditRepo := repository.NewAuditRepository(database.GetDB().WithContext(r.Context()))
+	entries, err := auditRepo.ListAuditLogs(filter, page)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to list audit log")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/database"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"skillswap/internal/services"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GetCategories returns the category tree with the number of active listings
+// in each category, counting subcategories
+func GetCategories(w http.ResponseWriter, r *http.Request) {
+	tree, err := services.NewCategoryService().GetCategoryTree(r.Context())
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get categories")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+// GetTagSuggestions autocompletes tags, most used first
+func GetTagSuggestions(w http.ResponseWriter, r *http.Request) {
+	limit := models.DefaultTagSuggestions
+	if value := r.URL.Query().Get("limit"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			apierror.Write(w, r, http.StatusBadRequest, "Invalid limit, expected a positive number")
+			return
+		}
+		limit = min(size, models.MaxTagSuggestions)
+	}
+
+	tagRepo := repository.NewTagRepository(database.GetDB().WithContext(r.Context()))
+	tags, err := tagRepo.SuggestTags(r.URL.Query().Get("prefix"), limit)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get tags")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TagSuggestions{Tags: tags})
+}
+
+func CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var categoryReq models.CategoryRequest
+	if !decodeJSON(w, r, &categoryReq) {
+		return
+	}
+
+	category := &models.Category{Name: categoryReq.Name, ParentID: categoryReq.ParentID}
+	categoryRepo := repository.NewCategoryRepository(database.GetDB().WithContext(r.Context()))
+	if err := categoryRepo.CreateCategory(category); err != nil {
+		writeCategoryError(w, r, err, "Failed to create category")
+		return
+	}
+	services.ClearCategoryCache()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+// UpdateCategory renames a category and sets its parent. Listings in it
+// follow it, and search for a category includes its subcategories.
+func UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var categoryReq models.CategoryRequest
+	if !decodeJSON(w, r, &categoryReq) {
+		return
+	}
+
+	categoryRepo := repository.NewCategoryRepository(database.GetDB().WithContext(r.Context()))
+	category, err := categoryRepo.UpdateCategory(mux.Vars(r)["id"], categoryReq.Name, categoryReq.ParentID)
+	if err != nil {
+		writeCategoryError(w, r, err, "Failed to update category")
+		return
+	}
+	services.ClearCategoryCache()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	categoryRepo := repository.NewCategoryRepository(database.GetDB().WithContext(r.Context()))
+	if err := categoryRepo.DeleteCategory(mux.Vars(r)["id"]); err != nil {
+		writeCategoryError(w, r, err, "Failed to delete category")
+		return
+	}
+	services.ClearCategoryCache()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeCategoryError answers the errors changing the category tree share
+func writeCategoryError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.Write(w, r, http.StatusNotFound, "Category not found")
+	case errors.Is(err, models.ErrCategorySlug):
+		apierror.WriteValidation(w, r, []apierror.FieldError{{Field: "name", Rule: "slug", Message: err.Error()}})
+	case errors.Is(err, repository.ErrParentNotFound):
+		apierror.WriteValidation(w, r, []apierror.FieldError{{Field: "parent_id", Rule: "exists", Message: "must be an existing category"}})
+	case errors.Is(err, repository.ErrCategoryCycle):
+		apierror.WriteValidation(w, r, []apierror.FieldError{{Field: "parent_id", Rule: "cycle", Message: "must not be the category or one below it"}})
+	case errors.Is(err, repository.ErrCategoryExists):
+		apierror.Write(w, r, http.StatusConflict, "A category with this name already exists")
+	case errors.Is(err, repository.ErrCategoryInUse):
+		apierror.Write(w, r, http.StatusConflict, "Category still has skills or subcategories")
+	default:
+		apierror.Write(w, r, http.StatusInternalServerError, message)
+	}
+}
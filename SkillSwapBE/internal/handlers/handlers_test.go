@@ -1,12 +1,25 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"encoding/json"
+
+	"skillswap/internal/devauth"
+	"skillswap/internal/middleware"
+	"skillswap/internal/testutil"
 )
 
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
 func TestHealthCheck(t *testing.T) {
 	req, err := http.NewRequest("GET", "/health", nil)
 	if err != nil {
@@ -35,9 +48,59 @@ func TestHealthCheck(t *testing.T) {
 	if response.Service != "SkillSwap Backend" {
 		t.Errorf("Expected service 'SkillSwap Backend', got '%s'", response.Service)
 	}
+
+	if response.Version == "" || response.Commit == "" {
+		t.Errorf("Expected build version and commit, got '%s' and '%s'", response.Version, response.Commit)
+	}
+}
+
+func TestReadinessCheckReportsEachDependency(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+	issuer, err := devauth.NewIssuer(key, "https://tenant.auth0.test/", "skillswapapi")
+	if err != nil {
+		t.Fatalf("failed to create issuer: %v", err)
+	}
+	tokenValidator, err := middleware.NewTokenValidator(
+		middleware.IdentityProvider{Name: "auth0", Issuer: issuer.Issuer(), Audience: issuer.Audience(), KeyFunc: issuer.KeyFunc},
+		middleware.IdentityProvider{Name: "corp", Issuer: "https://sso.corp.test/", Audience: "skillswap", KeyFunc: func(context.Context) (interface{}, error) {
+			return nil, errors.New("jwks unreachable")
+		}},
+	)
+	if err != nil {
+		t.Fatalf("NewTokenValidator returned error: %v", err)
+	}
+
+	// No database is connected in these tests
+	rr := httptest.NewRecorder()
+	ReadinessCheck(tokenValidator).ServeHTTP(rr, httptest.NewRequest("GET", "/health/ready", nil))
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	var response HealthResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	if response.Status != "unhealthy" {
+		t.Errorf("Expected status 'unhealthy', got '%s'", response.Status)
+	}
+	expected := map[string]string{"database": "down", "migrations": "down", "jwks:auth0": "up", "jwks:corp": "down"}
+	for name, status := range expected {
+		if got := response.Checks[name].Status; got != status {
+			t.Errorf("Expected %s to be %s, got '%s'", name, status, got)
+		}
+	}
+	if response.Checks["jwks:corp"].Error != "jwks unreachable" {
+		t.Errorf("Expected the key fetch error, got '%s'", response.Checks["jwks:corp"].Error)
+	}
 }
 
 func TestGetSkills(t *testing.T) {
+	testutil.NewTestDB(t)
 	req, err := http.NewRequest("GET", "/api/v1/skills", nil)
 	if err != nil {
 		t.Fatal(err)
@@ -1,27 +1,108 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"skillswap/internal/database"
+	"skillswap/internal/middleware"
+	"skillswap/internal/version"
 	"time"
 )
 
+// readinessTimeout bounds each dependency check so a hung dependency fails
+// the probe instead of hanging it
+const readinessTimeout = 3 * time.Second
+
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Service   string    `json:"service"`
-	Version   string    `json:"version"`
+	Status    string                       `json:"status"`
+	Timestamp time.Time                    `json:"timestamp"`
+	Service   string                       `json:"service"`
+	Version   string                       `json:"version"`
+	Commit    string                       `json:"commit"`
+	Checks    map[string]HealthCheckResult `json:"checks,omitempty"`
 }
 
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response := HealthResponse{
-		Status:    "healthy",
+// HealthCheckResult is the state of one dependency checked for readiness
+type HealthCheckResult struct {
+	Status     string `json:"status"` // "up" or "down"
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newHealthResponse(status string) HealthResponse {
+	return HealthResponse{
+		Status:    status,
 		Timestamp: time.Now(),
 		Service:   "SkillSwap Backend",
-		Version:   "1.0.0",
+		Version:   version.Version,
+		Commit:    version.Commit,
 	}
-	
+}
+
+// HealthCheck is the liveness probe: it answers as long as the process can
+// serve requests, without touching any dependency
+func HealthCheck(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, newHealthResponse("healthy"))
+}
+
+// ReadinessCheck is the readiness probe. It pings the database, checks the
+// schema is migrated to this build's version and that every identity
+// provider's signing keys can be loaded, answering 503 if any of them fail.
+func ReadinessCheck(tokenValidator *middleware.TokenValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := newHealthResponse("healthy")
+		response.Checks = make(map[string]HealthCheckResult)
+
+		check := func(name string, fn func(ctx context.Context) error) bool {
+			ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := fn(ctx)
+			result := HealthCheckResult{Status: "up", DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = "down"
+				result.Error = err.Error()
+				response.Status = "unhealthy"
+			}
+			response.Checks[name] = result
+			return err == nil
+		}
+
+		if check("database", database.Ping) {
+			check("migrations", func(ctx context.Context) error {
+				current, err := database.SchemaVersion(ctx)
+				if err != nil {
+					return err
+				}
+				if latest := database.LatestSchemaVersion(); current < latest {
+					return fmt.Errorf("schema is at version %d, expected %d", current, latest)
+				}
+				return nil
+			})
+		} else {
+			response.Checks["migrations"] = HealthCheckResult{Status: "down", Error: "database unavailable"}
+		}
+
+		for _, provider := range tokenValidator.ProviderNames() {
+			check("jwks:"+provider, func(ctx context.Context) error {
+				return tokenValidator.CheckSigningKeys(ctx, provider)
+			})
+		}
+
+		writeHealth(w, response)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, response HealthResponse) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Cache-Control", "no-store")
+	if response.Status == "healthy" {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/database"
+	"skillswap/internal/middleware"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"skillswap/internal/services"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+func GetMyIdentities(w http.ResponseWriter, r *http.Request) {
+	// Get user from context (created by EnsureUserExists middleware)
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	identityRepo := repository.NewIdentityRepository(database.GetDB().WithContext(r.Context()))
+	identities, err := identityRepo.ListIdentitiesByUser(user.ID, page)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get identities")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+}
+
+// LinkIdentity links the login behind a second provider's token to the current user.
+// The token proves the caller controls that login, so it is validated like a bearer token.
+func LinkIdentity(tokenValidator *middleware.TokenValidator) http.HandlerFunc {
+	userService := services.NewUserService()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value("user").(*models.User)
+		if !ok {
+			apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+			return
+		}
+
+		var linkReq models.LinkIdentityRequest
+		if !decodeJSON(w, r, &linkReq) {
+			return
+		}
+
+		claims, err := tokenValidator.Identity(r.Context(), linkReq.Token)
+		if err != nil {
+			apierror.Write(w, r, http.StatusBadRequest, "Invalid identity token")
+			return
+		}
+
+		identity, err := userService.LinkIdentity(r.Context(), user.ID, claims.Provider, claims.Sub)
+		if errors.Is(err, services.ErrIdentityInUse) {
+			apierror.Write(w, r, http.StatusConflict, "Identity is already linked to another account")
+			return
+		}
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "Failed to link identity")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(identity)
+	}
+}
+
+func UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	identityRepo := repository.NewIdentityRepository(database.GetDB().WithContext(r.Context()))
+	err := identityRepo.DeleteIdentity(user.ID, mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, repository.ErrLastIdentity):
+		apierror.Write(w, r, http.StatusConflict, "Cannot remove the last linked login")
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to unlink identity")
+		return
+	}
+	services.ForgetUser(user.ID) // So the unlinked login stops resolving to this user
+
+	w.WriteHeader(http.StatusNoContent)
+}
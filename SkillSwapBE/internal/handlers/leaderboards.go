@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/models"
+	"skillswap/internal/services"
+	"strconv"
+)
+
+// GetPointsLeaderboard ranks users by points earned in a weekly, monthly or
+// all-time window
+func GetPointsLeaderboard(w http.ResponseWriter, r *http.Request) {
+	query, ok := readLeaderboardQuery(w, r, models.BoardPoints)
+	if !ok {
+		return
+	}
+
+	query.Window = models.LeaderboardWindow(r.URL.Query().Get("window"))
+	if query.Window == "" {
+		query.Window = models.WindowAllTime
+	} else if !query.Window.IsValid() {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid window, expected weekly, monthly or all_time")
+		return
+	}
+
+	writeLeaderboard(w, r, query)
+}
+
+// GetRatingLeaderboard ranks teachers by average rating, leaving off those
+// with fewer than min_reviews reviews
+func GetRatingLeaderboard(w http.ResponseWriter, r *http.Request) {
+	query, ok := readLeaderboardQuery(w, r, models.BoardRating)
+	if !ok {
+		return
+	}
+
+	query.MinReviews = models.DefaultMinReviews
+	if value := r.URL.Query().Get("min_reviews"); value != "" {
+		minReviews, err := strconv.Atoi(value)
+		if err != nil || minReviews < 1 {
+			apierror.Write(w, r, http.StatusBadRequest, "Invalid min_reviews, expected a positive number")
+			return
+		}
+		query.MinReviews = minReviews
+	}
+
+	writeLeaderboard(w, r, query)
+}
+
+// readLeaderboardQuery reads the filters and size shared by every board. It
+// answers 400 and returns false when the size is invalid.
+func readLeaderboardQuery(w http.ResponseWriter, r *http.Request, board models.LeaderboardBoard) (models.LeaderboardQuery, bool) {
+	params := r.URL.Query()
+	query := models.LeaderboardQuery{
+		Board:    board,
+		Category: params.Get("category"),
+		Location: params.Get("location"),
+		Size:     models.DefaultLeaderboardSize,
+	}
+
+	if value := params.Get("limit"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			apierror.Write(w, r, http.StatusBadRequest, "Invalid limit, expected a positive number")
+			return models.LeaderboardQuery{}, false
+		}
+		query.Size = min(size, models.MaxLeaderboardSize)
+	}
+	return query, true
+}
+
+func writeLeaderboard(w http.ResponseWriter, r *http.Request, query models.LeaderboardQuery) {
+	leaderboard, err := services.NewLeaderboardService().GetLeaderboard(r.Context(), query)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get leaderboard")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leaderboard)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/media"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"skillswap/internal/services"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// signedMediaExpiry is how long a link handed out for storage that signs
+// them stays valid. Redirects are cached for a little less.
+const signedMediaExpiry = time.Hour
+
+// UploadAvatar replaces the current user's avatar with the image in the
+// multipart "file" field
+func UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	data, ok := readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	image, err := services.NewMediaService().SetAvatar(r.Context(), user.ID, requestHost(r), data)
+	if err != nil {
+		writeMediaError(w, r, err, "Failed to upload avatar")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(image)
+}
+
+func DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	if err := services.NewMediaService().RemoveAvatar(r.Context(), user.ID); err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to remove avatar")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadSkillImage adds the image in the multipart "file" field to the
+// gallery of one of the current user's skills
+func UploadSkillImage(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	skillID, ok := pathID(w, r, "id", "Skill not found")
+	if !ok {
+		return
+	}
+	data, ok := readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	image, err := services.NewMediaService().AddSkillImage(r.Context(), user.ID, skillID, requestHost(r), data)
+	switch {
+	case errors.Is(err, repository.ErrGalleryFull):
+		apierror.Write(w, r, http.StatusConflict, fmt.Sprintf("A skill can have at most %d images", models.MaxSkillImages))
+		return
+	case errors.Is(err, repository.ErrDuplicateImage):
+		apierror.Write(w, r, http.StatusConflict, "Image is already in the gallery")
+		return
+	case err != nil:
+		writeMediaError(w, r, err, "Failed to upload image")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(image)
+}
+
+func DeleteSkillImage(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	skillID, ok := pathID(w, r, "id", "Skill not found")
+	if !ok {
+		return
+	}
+	imageID, ok := pathID(w, r, "imageId", "Image not found")
+	if !ok {
+		return
+	}
+
+	err := services.NewMediaService().RemoveSkillImage(r.Context(), user.ID, skillID, imageID)
+	if err != nil {
+		writeMediaError(w, r, err, "Failed to remove image")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMedia serves an uploaded image. Storage that can sign links is
+// redirected to, so the file doesn't pass through the API.
+func GetMedia(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	key := vars["kind"] + "/" + vars["owner"] + "/" + vars["hash"] + "/" + vars["file"]
+	if !media.ValidKey(key) {
+		apierror.Write(w, r, http.StatusNotFound, "Media not found")
+		return
+	}
+
+	storage := media.CurrentStorage()
+	if signer, ok := storage.(media.Signer); ok {
+		link, err := signer.SignedURL(key, signedMediaExpiry)
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "Failed to get media")
+			return
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int((signedMediaExpiry-5*time.Minute).Seconds())))
+		http.Redirect(w, r, link, http.StatusFound)
+		return
+	}
+
+	file, err := storage.Get(r.Context(), key)
+	if errors.Is(err, media.ErrNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "Media not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get media")
+		return
+	}
+	defer file.Close()
+
+	// Keys change whenever the content does, so files never need revalidating
+	w.Header().Set("Content-Type", media.ContentType(key))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, file); err != nil {
+		slog.Warn("Failed to send media", "key", key, "error", err)
+	}
+}
+
+// readUpload reads the multipart "file" field, writing an error response
+// and returning false if there isn't one or it is too large
+func readUpload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	// Leave room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadSize+64<<10)
+
+	file, _, err := r.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		apierror.Write(w, r, http.StatusRequestEntityTooLarge, media.ErrTooLarge.Error())
+		return nil, false
+	case err != nil:
+		apierror.Write(w, r, http.StatusBadRequest, "Request must be multipart/form-data with the image in a \"file\" field")
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadSize+1))
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "Failed to read upload")
+		return nil, false
+	}
+	return data, true
+}
+
+// writeMediaError answers the errors uploading and removing media share
+func writeMediaError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		apierror.Write(w, r, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, media.ErrUnsupportedType):
+		apierror.Write(w, r, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, media.ErrTooManyPixels), errors.Is(err, media.ErrUnreadable):
+		apierror.Write(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotSkillOwner):
+		apierror.Write(w, r, http.StatusForbidden, "You can only change images of your own skills")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.Write(w, r, http.StatusNotFound, "Skill or image not found")
+	default:
+		apierror.Write(w, r, http.StatusInternalServerError, message)
+	}
+}
+
+// requestHost is the scheme and host a request was made to
+func requestHost(r *http.Request) string {
+	if r.TLS != nil {
+		return "https://" + r.Host
+	}
+	return "http://" + r.Host
+}
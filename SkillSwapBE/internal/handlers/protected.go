@@ -2,17 +2,23 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/badges"
 	"skillswap/internal/database"
 	"skillswap/internal/middleware"
 	"skillswap/internal/models"
+	"skillswap/internal/pagination"
 	"skillswap/internal/repository"
+	"skillswap/internal/services"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 type DashboardData struct {
-	User          *models.User `json:"user"`
-	MySkills      []models.Skill `json:"my_skills"`
+	User          models.AccountUser `json:"user"`
+	MySkills      []models.Skill `json:"my_skills"` // Most recent first page; /my-skills pages through the rest
 	RecentBookings []models.Booking `json:"recent_bookings"`
 	Stats         *UserStats `json:"stats"`
 }
@@ -26,45 +32,61 @@ type UserStats struct {
 	Rank              string `json:"rank"`
 }
 
+// ProfileResponse embeds the first page of the user's skills and reviews;
+// /profile/{id}/skills and /profile/{id}/reviews page through the rest. User
+// is a models.AccountUser on your own profile and a models.MemberUser on
+// anyone else's.
 type ProfileResponse struct {
-	User    *models.User          `json:"user"`
-	Skills  []models.Skill        `json:"skills"`
-	Reviews []models.Review       `json:"reviews"`
-	Summary *models.ReviewSummary `json:"review_summary"`
+	User    interface{}                    `json:"user"`
+	Skills  pagination.List[models.Skill]  `json:"skills"`
+	Reviews pagination.List[models.Review] `json:"reviews"`
+	Summary *models.ReviewSummary          `json:"review_summary"`
+	Badges  []models.EarnedBadge           `json:"badges"`
 }
 
 func GetUserDashboard(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (created by EnsureUserExists middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "Unable to get user information", http.StatusUnauthorized)
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
 		return
 	}
 
 	// Get JWT claims for email
 	claims, err := middleware.GetUserFromContext(r.Context())
 	if err != nil {
-		http.Error(w, "Unable to get user claims", http.StatusUnauthorized)
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user claims")
 		return
 	}
 
-	// Get user profile with relationships
-	db := database.GetDB()
+	// Get user profile and the first page of their skills
+	db := database.GetDB().WithContext(r.Context())
 	userRepo := repository.NewUserRepository(db)
+	skillRepo := repository.NewSkillRepository(db)
 	
 	profile, err := userRepo.GetUserProfile(user.ID)
 	if err != nil {
-		http.Error(w, "Failed to get user profile", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user profile")
+		return
+	}
+	skills, err := skillRepo.ListActiveSkillsByUser(user.ID, pagination.First())
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user skills")
+		return
+	}
+	skillCount, err := skillRepo.CountActiveSkillsByUser(user.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user skills")
 		return
 	}
 
 	// Add email from JWT claims to user profile
-	profileWithEmail := *profile
-	profileWithEmail.Email = claims.Email
+	account := profile.Account()
+	account.Email = claims.Email
 
 	// Calculate user stats
 	stats := &UserStats{
-		TotalSkillsOffered: len(profile.Skills),
+		TotalSkillsOffered: int(skillCount),
 		TotalBookings:      0, // TODO: Calculate from bookings
 		TotalEarnings:      0, // TODO: Calculate from completed bookings
 		AverageRating:      profile.Rating,
@@ -73,8 +95,8 @@ func GetUserDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	dashboardData := DashboardData{
-		User:          &profileWithEmail,
-		MySkills:      profile.Skills,
+		User:          account,
+		MySkills:      skills.Data,
 		RecentBookings: []models.Booking{}, // TODO: Get recent bookings
 		Stats:         stats,
 	}
@@ -94,28 +116,27 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	if userID == "" {
 		userClaims, err := middleware.GetUserFromContext(r.Context())
 		if err != nil {
-			http.Error(w, "Unable to get user information", http.StatusUnauthorized)
+			apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
 			return
 		}
 		currentUserClaims = userClaims
 		isCurrentUser = true
 		
-		db := database.GetDB()
-		userRepo := repository.NewUserRepository(db)
-		
-		user, err := userRepo.GetUserByAuth0ID(userClaims.Sub)
-		if err != nil {
-			http.Error(w, "User not found", http.StatusNotFound)
+		user, ok := r.Context().Value("user").(*models.User)
+		if !ok {
+			apierror.Write(w, r, http.StatusNotFound, "User not found")
 			return
 		}
 		userID = user.ID
 	} else {
+		if _, ok := pathID(w, r, "id", "User not found"); !ok {
+			return
+		}
+
 		// Check if this is the current user's own profile
 		if userClaims, err := middleware.GetUserFromContext(r.Context()); err == nil {
-			db := database.GetDB()
-			userRepo := repository.NewUserRepository(db)
-			currentUser, err := userRepo.GetUserByAuth0ID(userClaims.Sub)
-			if err == nil && currentUser.ID == userID {
+			currentUser, ok := r.Context().Value("user").(*models.User)
+			if ok && currentUser.ID == userID {
 				isCurrentUser = true
 				currentUserClaims = userClaims
 			}
@@ -123,43 +144,71 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get repositories
-	db := database.GetDB()
+	db := database.GetDB().WithContext(r.Context())
 	userRepo := repository.NewUserRepository(db)
+	skillRepo := repository.NewSkillRepository(db)
 	reviewRepo := repository.NewReviewRepository(db)
 
-	// Get user profile
-	user, err := userRepo.GetUserProfile(userID)
+	// Get user profile. Anyone else only sees users in the public directory.
+	getUser := userRepo.GetPublicUser
+	if isCurrentUser {
+		getUser = userRepo.GetUserProfile
+	}
+	user, err := getUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "User not found")
+		return
+	}
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user")
 		return
 	}
 
-	// Add email from JWT claims if this is the current user
+	// Only the current user sees their own account details, with the email
+	// from their JWT claims
+	var profileUser interface{} = user.Member()
 	if isCurrentUser && currentUserClaims != nil {
-		userWithEmail := *user
-		userWithEmail.Email = currentUserClaims.Email
-		user = &userWithEmail
+		account := user.Account()
+		account.Email = currentUserClaims.Email
+		profileUser = account
 	}
 
-	// Get reviews
-	reviews, err := reviewRepo.GetReviewsByUser(userID, true)
+	// Get the first page of skills and reviews. A suspended user still sees
+	// their own listings.
+	listSkills := skillRepo.ListPublicSkillsByUser
+	if isCurrentUser {
+		listSkills = skillRepo.ListActiveSkillsByUser
+	}
+	skills, err := listSkills(userID, pagination.First())
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get skills")
+		return
+	}
+	reviews, err := reviewRepo.GetReviewsByUser(userID, true, pagination.First())
 	if err != nil {
-		http.Error(w, "Failed to get reviews", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get reviews")
 		return
 	}
 
 	// Get review summary
 	summary, err := reviewRepo.GetReviewSummary(userID)
 	if err != nil {
-		http.Error(w, "Failed to get review summary", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get review summary")
+		return
+	}
+
+	earned, err := badges.Earned(db, userID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get badges")
 		return
 	}
 
 	profileResponse := ProfileResponse{
-		User:    user,
-		Skills:  user.Skills,
+		User:    profileUser,
+		Skills:  skills,
 		Reviews: reviews,
 		Summary: summary,
+		Badges:  earned,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -167,66 +216,179 @@ func GetUserProfile(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetMySkills(w http.ResponseWriter, r *http.Request) {
-	userClaims, err := middleware.GetUserFromContext(r.Context())
+	// Get user from context (created by EnsureUserExists middleware)
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	skillRepo := repository.NewSkillRepository(database.GetDB().WithContext(r.Context()))
+	skills, err := skillRepo.ListActiveSkillsByUser(user.ID, page)
 	if err != nil {
-		http.Error(w, "Unable to get user information", http.StatusUnauthorized)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user skills")
 		return
 	}
 
-	db := database.GetDB()
-	userRepo := repository.NewUserRepository(db)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(skills)
+}
 
-	// Get user
-	user, err := userRepo.GetUserByAuth0ID(userClaims.Sub)
+// GetProfileSkills pages through the active skills on a user's profile. A
+// suspended user's listings are hidden from everyone but them.
+func GetProfileSkills(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "User not found")
+	if !ok {
+		return
+	}
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	skillRepo := repository.NewSkillRepository(database.GetDB().WithContext(r.Context()))
+	listSkills := skillRepo.ListPublicSkillsByUser
+	if user, ok := r.Context().Value("user").(*models.User); ok && user.ID == userID {
+		listSkills = skillRepo.ListActiveSkillsByUser
+	}
+	skills, err := listSkills(userID, page)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get skills")
 		return
 	}
 
-	// Get user profile with skills
-	profile, err := userRepo.GetUserProfile(user.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(skills)
+}
+
+// GetProfileReviews pages through the public reviews on a user's profile
+func GetProfileReviews(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathID(w, r, "id", "User not found")
+	if !ok {
+		return
+	}
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	reviewRepo := repository.NewReviewRepository(database.GetDB().WithContext(r.Context()))
+	reviews, err := reviewRepo.GetReviewsByUser(userID, true, page)
 	if err != nil {
-		http.Error(w, "Failed to get user skills", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get reviews")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(profile.Skills)
+	json.NewEncoder(w).Encode(reviews)
+}
+
+// GetMyBadges shows the current user's progress towards every badge, earned
+// or not. There are few enough badges to always fit on one page.
+func GetMyBadges(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	progress, err := badges.Progress(database.GetDB().WithContext(r.Context()), user.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get badges")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pagination.List[models.BadgeProgress]{Data: progress})
 }
 
 func UpdateUserProfile(w http.ResponseWriter, r *http.Request) {
 	// Get user from context (created by EnsureUserExists middleware)
 	user, ok := r.Context().Value("user").(*models.User)
 	if !ok {
-		http.Error(w, "Unable to get user information", http.StatusUnauthorized)
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
 		return
 	}
 
 	// Parse request body
 	var updateReq models.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &updateReq) {
 		return
 	}
 
-	// Get database connection
-	db := database.GetDB()
-	userRepo := repository.NewUserRepository(db)
+	// An unchanged username isn't a rename
+	if updateReq.Username == user.Username {
+		updateReq.Username = ""
+	}
 
-	// Update user profile
-	err := userRepo.UpdateUser(user.ID, &updateReq)
-	if err != nil {
-		http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+	// Rename, hold suspicious free text back for a moderator and apply the
+	// rest. A taken or invalid username rejects the whole update.
+	held, err := services.NewUserService().UpdateProfile(r.Context(), user.ID, &updateReq)
+	switch {
+	case errors.Is(err, services.ErrUsernameInvalid):
+		apierror.WriteValidation(w, r, []apierror.FieldError{{Field: "username", Rule: "username", Message: err.Error()}})
+		return
+	case errors.Is(err, services.ErrUsernameReserved):
+		apierror.WriteValidation(w, r, []apierror.FieldError{{Field: "username", Rule: "reserved", Message: err.Error()}})
+		return
+	case errors.Is(err, services.ErrUsernameNotAllowed):
+		apierror.WriteValidation(w, r, []apierror.FieldError{{Field: "username", Rule: "moderation", Message: err.Error()}})
+		return
+	case errors.Is(err, services.ErrUsernameTaken):
+		apierror.Write(w, r, http.StatusConflict, "Username is taken")
+		return
+	case err != nil:
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to update profile")
 		return
 	}
 
 	// Get updated profile
+	userRepo := repository.NewUserRepository(database.GetDB().WithContext(r.Context()))
 	updatedProfile, err := userRepo.GetUserProfile(user.ID)
 	if err != nil {
-		http.Error(w, "Failed to get updated profile", http.StatusInternalServerError)
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get updated profile")
 		return
 	}
 
+	account := updatedProfile.Account()
+	if claims, err := middleware.GetUserFromContext(r.Context()); err == nil {
+		account.Email = claims.Email
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedProfile)
-}
\ No newline at end of file
+	if len(held) > 0 {
+		// The rest of the update went through, the held fields await review
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(account)
+}
+
+// CheckUsernameAvailability tells the current user whether they could change
+// to a username, and why not
+func CheckUsernameAvailability(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		apierror.Write(w, r, http.StatusBadRequest, "Missing username")
+		return
+	}
+
+	availability, err := services.NewUsernameService().CheckAvailability(r.Context(), user.ID, username)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to check username")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(availability)
+}
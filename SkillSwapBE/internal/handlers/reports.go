@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/database"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"skillswap/internal/services"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// CreateReport returns a handler that reports the {id} of the given target type
+func CreateReport(targetType models.ReportTargetType) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value("user").(*models.User)
+		if !ok {
+			apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+			return
+		}
+
+		var reportReq models.CreateReportRequest
+		if !decodeJSON(w, r, &reportReq) {
+			return
+		}
+
+		moderationService := services.NewModerationService()
+		report, err := moderationService.ReportContent(r.Context(), user.ID, targetType, mux.Vars(r)["id"], reportReq)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apierror.Write(w, r, http.StatusNotFound, "Not found")
+			return
+		}
+		if errors.Is(err, services.ErrAlreadyReported) {
+			apierror.Write(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			apierror.Write(w, r, http.StatusInternalServerError, "Failed to create report")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+func ListReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := models.ReportStatus(query.Get("status"))
+	if status == "" {
+		status = models.ReportOpen
+	} else if status == "all" {
+		status = ""
+	} else if !status.IsValid() {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	reportRepo := repository.NewReportRepository(database.GetDB().WithContext(r.Context()))
+	reports, err := reportRepo.ListReports(status, models.ReportTargetType(query.Get("target_type")), page)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to list reports")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+func GetReport(w http.ResponseWriter, r *http.Request) {
+	reportID, ok := pathID(w, r, "id", "Report not found")
+	if !ok {
+		return
+	}
+
+	reportRepo := repository.NewReportRepository(database.GetDB().WithContext(r.Context()))
+	report, err := reportRepo.GetReportByID(reportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "Report not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func ResolveReport(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := r.Context().Value("user").(*models.User)
+	if !ok {
+		apierror.Write(w, r, http.StatusUnauthorized, "Unable to get user information")
+		return
+	}
+	reportID, ok := pathID(w, r, "id", "Report not found")
+	if !ok {
+		return
+	}
+
+	var resolveReq models.ResolveReportRequest
+	if !decodeJSON(w, r, &resolveReq) {
+		return
+	}
+
+	moderationService := services.NewModerationService()
+	report, err := moderationService.ResolveReport(r.Context(), reportID, moderator.ID, resolveReq)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "Report not found")
+		return
+	}
+	if errors.Is(err, services.ErrReportResolved) {
+		apierror.Write(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to resolve report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/pagination"
+	"skillswap/internal/repository"
+	"skillswap/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// decodeJSON reads a JSON request body into dst and enforces its binding
+// tags. It answers 400 for a body that can't be parsed or 422 listing the
+// invalid fields, and returns false, when the request can't go on.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	if fields := validation.Struct(dst); fields != nil {
+		apierror.WriteValidation(w, r, fields)
+		return false
+	}
+	return true
+}
+
+// readPage reads the limit and cursor query parameters. It answers 400 and
+// returns false when either is invalid.
+func readPage(w http.ResponseWriter, r *http.Request) (pagination.Page, bool) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid page: "+err.Error())
+		return pagination.Page{}, false
+	}
+	return page, true
+}
+
+// pathID reads the named ID from the URL path. An ID that isn't a UUID can't
+// match anything, so it answers 404 with message and returns false rather
+// than letting Postgres reject it.
+func pathID(w http.ResponseWriter, r *http.Request, name, message string) (string, bool) {
+	id := mux.Vars(r)[name]
+	if !repository.ValidID(id) {
+		apierror.Write(w, r, http.StatusNotFound, message)
+		return "", false
+	}
+	return id, true
+}
@@ -2,76 +2,140 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"skillswap/internal/apierror"
+	"skillswap/internal/badges"
+	"skillswap/internal/database"
 	"skillswap/internal/models"
-	"time"
+	"skillswap/internal/pagination"
+	"skillswap/internal/repository"
+	"skillswap/internal/services"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
-var mockUsers = []models.User{
-	{
-		ID:        "user1",
-		Username:  "guitarmaster",
-		Email:     "john@example.com",
-		FullName:  "John Smith",
-		Location:  "San Francisco, CA",
-		Rating:    4.8,
-		CreatedAt: time.Now().AddDate(0, -6, 0),
-		UpdatedAt: time.Now().AddDate(0, 0, -1),
-	},
-	{
-		ID:        "user2",
-		Username:  "webdev_pro",
-		Email:     "sarah@example.com",
-		FullName:  "Sarah Johnson",
-		Location:  "San Francisco, CA",
-		Rating:    4.9,
-		CreatedAt: time.Now().AddDate(0, -4, 0),
-		UpdatedAt: time.Now().AddDate(0, 0, -2),
-	},
-	{
-		ID:        "user3",
-		Username:  "photoartist",
-		Email:     "mike@example.com",
-		FullName:  "Mike Davis",
-		Location:  "San Francisco, CA",
-		Rating:    4.7,
-		CreatedAt: time.Now().AddDate(0, -8, 0),
-		UpdatedAt: time.Now().AddDate(0, 0, -3),
-	},
-}
-
+// GetUsers lists the public directory, filtered by rank, location and skill
+// category, and sorted by newest, rating or points
 func GetUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := models.UserDirectoryFilter{
+		Rank:     models.UserRank(query.Get("rank")),
+		Location: query.Get("location"),
+		Category: query.Get("category"),
+		Sort:     models.UserSort(query.Get("sort")),
+	}
+	if filter.Rank != "" && !filter.Rank.IsValid() {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid rank")
+		return
+	}
+	if filter.Sort == "" {
+		filter.Sort = models.SortNewest
+	} else if !filter.Sort.IsValid() {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid sort, expected newest, rating or points")
+		return
+	}
+
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	userRepo := repository.NewUserRepository(database.GetDB().WithContext(r.Context()))
+	users, err := userRepo.ListPublicUsers(filter, page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		apierror.Write(w, r, http.StatusBadRequest, "Invalid page: the cursor is for a different sort")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(mockUsers)
+	json.NewEncoder(w).Encode(pagination.Map(users, models.User.Public))
 }
 
+// GetUserByID returns a user's public profile with the badges they've earned
 func GetUserByID(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID := vars["id"]
-	
-	for _, user := range mockUsers {
-		if user.ID == userID {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(user)
-			return
-		}
-	}
-	
-	http.NotFound(w, r)
+	userID, ok := pathID(w, r, "id", "User not found")
+	if !ok {
+		return
+	}
+
+	db := database.GetDB().WithContext(r.Context())
+	user, err := repository.NewUserRepository(db).GetPublicUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	writePublicProfile(w, r, db, user)
+}
+
+// GetUserByUsername returns the public profile behind a vanity URL, ignoring
+// case. A username the user gave up recently redirects to their current one.
+func GetUserByUsername(w http.ResponseWriter, r *http.Request) {
+	user, moved, err := services.NewUsernameService().ResolveUsername(r.Context(), mux.Vars(r)["username"])
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+	if moved {
+		w.Header().Set("Location", "/api/v1/public/users/by-username/"+url.PathEscape(user.Username))
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+	writePublicProfile(w, r, database.GetDB().WithContext(r.Context()), user)
+}
+
+func writePublicProfile(w http.ResponseWriter, r *http.Request, db *gorm.DB, user *models.User) {
+	earned, err := badges.Earned(db, user.ID)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get badges")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.PublicProfile{PublicUser: user.Public(), Badges: earned})
 }
 
 func GetUserSkills(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID := vars["id"]
-	
-	var userSkills []models.Skill
-	for _, skill := range mockSkills {
-		if skill.UserID == userID {
-			userSkills = append(userSkills, skill)
-		}
-	}
-	
+	userID, ok := pathID(w, r, "id", "User not found")
+	if !ok {
+		return
+	}
+	page, ok := readPage(w, r)
+	if !ok {
+		return
+	}
+
+	db := database.GetDB().WithContext(r.Context())
+
+	// Skills are only listed for users who are in the directory
+	if _, err := repository.NewUserRepository(db).GetPublicUser(userID); errors.Is(err, gorm.ErrRecordNotFound) {
+		apierror.Write(w, r, http.StatusNotFound, "User not found")
+		return
+	} else if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+
+	skills, err := repository.NewSkillRepository(db).ListActiveSkillsByUser(userID, page)
+	if err != nil {
+		apierror.Write(w, r, http.StatusInternalServerError, "Failed to get skills")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(userSkills)
-}
\ No newline at end of file
+	json.NewEncoder(w).Encode(skills)
+}
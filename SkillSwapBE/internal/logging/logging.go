@@ -0,0 +1,112 @@
+// Package logging configures structured logging through log/slog and carries
+// per-request details, such as the request ID, for log lines to pick up.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New builds a logger writing JSON, or text when format is "text", at the
+// given level ("debug", "info", "warn" or "error"; info when empty)
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", level)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: minLevel}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected json or text", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Setup makes a logger writing to stdout the default, which also routes the
+// standard log package through it
+func Setup(level, format string) error {
+	logger, err := New(os.Stdout, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// requestInfo is shared by pointer so that middleware further down the chain,
+// which only sees a derived context, can fill in the user for the access log
+type requestInfo struct {
+	id     string
+	userID string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestID starts tracking a request's details under its ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, &requestInfo{id: requestID})
+}
+
+// RequestID returns the ID of the request ctx belongs to, or ""
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// SetUserID records the authenticated user for the rest of the request
+func SetUserID(ctx context.Context, userID string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userID = userID
+	}
+}
+
+// UserID returns the authenticated user of the request ctx belongs to, or ""
+func UserID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.userID
+	}
+	return ""
+}
+
+// contextHandler adds the request ID, user ID and trace ID to records logged
+// with a request context, e.g. through slog.InfoContext
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		record.AddAttrs(slog.String("request_id", info.id))
+		if info.userID != "" {
+			record.AddAttrs(slog.String("user_id", info.userID))
+		}
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
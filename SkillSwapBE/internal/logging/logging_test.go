@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestLoggerAddsRequestDetails(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", "json")
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	SetUserID(ctx, "user-1")
+	logger.InfoContext(ctx, "hello")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON log line, got %q", buf.String())
+	}
+	if line["request_id"] != "req-1" || line["user_id"] != "user-1" || line["msg"] != "hello" {
+		t.Errorf("Unexpected log line: %v", line)
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "WARN", "text")
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Debug("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("Expected info and debug lines to be dropped, got %q", buf.String())
+	}
+	logger.Log(context.Background(), slog.LevelWarn, "shown")
+	if !bytes.Contains(buf.Bytes(), []byte("shown")) {
+		t.Errorf("Expected the warning to be logged, got %q", buf.String())
+	}
+
+	if _, err := New(&buf, "loud", ""); err == nil {
+		t.Error("Expected error for an unknown level")
+	}
+	if _, err := New(&buf, "", "xml"); err == nil {
+		t.Error("Expected error for an unknown format")
+	}
+}
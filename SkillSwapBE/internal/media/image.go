@@ -0,0 +1,221 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // Registers GIF with image.Decode
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+)
+
+const (
+	// MaxUploadSize is the largest file accepted, in bytes
+	MaxUploadSize = 5 << 20
+	// MaxPixels caps the decoded size, so a small file can't expand into a huge image
+	MaxPixels   = 40_000_000
+	jpegQuality = 85
+)
+
+var (
+	ErrTooLarge        = fmt.Errorf("image must be at most %d MB", MaxUploadSize>>20)
+	ErrTooManyPixels   = fmt.Errorf("image must be at most %d megapixels", MaxPixels/1_000_000)
+	ErrUnsupportedType = errors.New("image must be a JPEG, PNG or GIF")
+	ErrUnreadable      = errors.New("image could not be read")
+)
+
+// Kind is what an image is uploaded for, which decides how it is sized
+type Kind struct {
+	Dir       string // First part of the storage key
+	Square    bool   // Crop to a centred square
+	FullSize  int    // Longest side of the full image, in pixels
+	ThumbSize int    // Longest side of the thumbnail, in pixels
+}
+
+var (
+	Avatar     = Kind{Dir: "avatars", Square: true, FullSize: 512, ThumbSize: 128}
+	SkillImage = Kind{Dir: "skills", FullSize: 1600, ThumbSize: 400}
+)
+
+// Image is an upload decoded and encoded again, which leaves EXIF and any
+// other metadata behind. JPEGs stay JPEGs; PNGs and GIFs become PNGs, and
+// only the first frame of an animated GIF is kept.
+type Image struct {
+	Full, Thumb   []byte
+	Ext           string // "jpg" or "png"
+	Width, Height int    // Of Full
+	Hash          string // Of the original upload
+}
+
+// Process checks an upload's type and size, turns it upright, crops it for
+// kind and encodes a full image and a thumbnail. Neither is scaled up.
+func Process(data []byte, kind Kind) (*Image, error) {
+	if len(data) > MaxUploadSize {
+		return nil, ErrTooLarge
+	}
+
+	// Trust the bytes, not the file name or the client's content type
+	format := http.DetectContentType(data)
+	switch format {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnreadable
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnreadable
+	}
+
+	if format == "image/jpeg" {
+		src = orient(src, jpegOrientation(data))
+	}
+	if kind.Square {
+		src = cropSquare(src)
+	}
+
+	full := fit(src, kind.FullSize)
+	result := &Image{Ext: "png", Width: full.Bounds().Dx(), Height: full.Bounds().Dy()}
+	if format == "image/jpeg" {
+		result.Ext = "jpg"
+	}
+	if result.Full, err = encode(full, result.Ext); err != nil {
+		return nil, err
+	}
+	if result.Thumb, err = encode(fit(src, kind.ThumbSize), result.Ext); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	result.Hash = hex.EncodeToString(sum[:16])
+	return result, nil
+}
+
+// Upload is a processed image in storage
+type Upload struct {
+	Key           string // Of the full image
+	ThumbKey      string
+	Width, Height int
+}
+
+// Save processes an upload and stores both sizes under the owner's ID, the
+// user or skill the image belongs to
+func Save(ctx context.Context, kind Kind, ownerID string, data []byte) (*Upload, error) {
+	img, err := Process(data, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := kind.Dir + "/" + ownerID + "/" + img.Hash + "/"
+	upload := &Upload{
+		Key:      prefix + "full." + img.Ext,
+		ThumbKey: prefix + "thumb." + img.Ext,
+		Width:    img.Width,
+		Height:   img.Height,
+	}
+	if err := storage.Put(ctx, upload.Key, ContentType(upload.Key), img.Full); err != nil {
+		return nil, err
+	}
+	if err := storage.Put(ctx, upload.ThumbKey, ContentType(upload.ThumbKey), img.Thumb); err != nil {
+		storage.Delete(ctx, upload.Key)
+		return nil, err
+	}
+	return upload, nil
+}
+
+// Remove deletes both sizes of the upload whose full image is stored under key
+func Remove(ctx context.Context, key string) error {
+	if err := storage.Delete(ctx, ThumbKey(key)); err != nil {
+		return err
+	}
+	return storage.Delete(ctx, key)
+}
+
+// ThumbKey returns where the thumbnail for the full image under key is stored
+func ThumbKey(key string) string {
+	return path.Dir(key) + "/thumb" + path.Ext(key)
+}
+
+func encode(img image.Image, ext string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if ext == "jpg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	return buf.Bytes(), err
+}
+
+// cropSquare keeps the largest centred square
+func cropSquare(src image.Image) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(x0, y0), draw.Src)
+	return dst
+}
+
+// fit scales src down so its longest side is at most size
+func fit(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return src
+	}
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+	return resize(src, w, h)
+}
+
+// resize scales src down to w by h, averaging every source pixel that falls
+// within a target pixel so fine detail doesn't alias
+func resize(src image.Image, w, h int) *image.RGBA {
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+	sw, sh := b.Dx(), b.Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+
+			var r, g, bl, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r, g, bl, a = r+int(p[0]), g+int(p[1]), bl+int(p[2]), a+int(p[3])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(bl/n), uint8(a/n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,100 @@
+// Package media turns uploaded images into safe, resized copies and keeps them
+// in a Storage, either on the local filesystem or in an S3-compatible bucket.
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a key has nothing stored under it
+	ErrNotFound = errors.New("media not found")
+	// ErrInvalidKey is returned for a key that storage would not have created
+	ErrInvalidKey = errors.New("invalid media key")
+)
+
+// Storage keeps processed media under slash-separated keys such as
+// "avatars/<user id>/<hash>/thumb.jpg"
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Get returns ErrNotFound when nothing is stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete succeeds when nothing is stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// Signer is implemented by storage that can hand out time-limited links, so
+// clients fetch media from the store rather than through the API
+type Signer interface {
+	SignedURL(key string, expires time.Duration) (string, error)
+}
+
+// keyPattern matches the keys Save creates. Anything else is refused, so a
+// key taken from a URL can't escape the storage root.
+var keyPattern = regexp.MustCompile(`^(avatars|skills)/[0-9a-f-]{36}/[0-9a-f]{32}/(full|thumb)\.(jpg|png)$`)
+
+// ValidKey reports whether key is one Save could have created
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// ContentType returns the MIME type of the file stored under key
+func ContentType(key string) string {
+	if path.Ext(key) == ".png" {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+// PathPrefix is where the API serves media, under the public routes
+const PathPrefix = "/api/v1/public/media/"
+
+var (
+	storage Storage = NewLocalStorage(DefaultDir)
+	baseURL string
+)
+
+// SetStorage replaces the storage used by Save, Remove and the media
+// handler, e.g. at startup
+func SetStorage(s Storage) {
+	storage = s
+}
+
+// CurrentStorage returns the storage set with SetStorage
+func CurrentStorage() Storage {
+	return storage
+}
+
+// SetBaseURL sets the public URL of the API, e.g. "https://api.example.com",
+// which links to media start with. When it's empty links use the host each
+// upload was made to.
+func SetBaseURL(url string) {
+	baseURL = strings.TrimSuffix(url, "/")
+}
+
+// URL is the public link to the file stored under key. host is the API's
+// scheme and host as seen by the uploader, used when no base URL is set.
+func URL(host, key string) string {
+	base := baseURL
+	if base == "" {
+		base = host
+	}
+	return base + PathPrefix + key
+}
+
+// KeyFromURL returns the key a link made by URL points to. It reports false
+// for any other link, such as an avatar hosted elsewhere.
+func KeyFromURL(link string) (string, bool) {
+	i := strings.Index(link, PathPrefix)
+	if i < 0 {
+		return "", false
+	}
+	key := link[i+len(PathPrefix):]
+	return key, ValidKey(key)
+}
@@ -0,0 +1,246 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat    = "20060102T150405Z"
+	amzAlgorithm     = "AWS4-HMAC-SHA256"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	maxSignedExpires = 7 * 24 * time.Hour // The longest S3 accepts
+)
+
+// S3Config locates a bucket on AWS S3 or any store that speaks its API, such
+// as MinIO or Cloudflare R2
+type S3Config struct {
+	Endpoint        string // e.g. "https://s3.eu-west-2.amazonaws.com"
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool // Address the bucket as endpoint/bucket rather than bucket.endpoint, as most S3-compatible stores expect
+}
+
+// S3Storage keeps files in an S3-compatible bucket, signing requests with
+// AWS Signature Version 4. The bucket can stay private: clients are handed
+// presigned links.
+type S3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time // Overridden in tests
+}
+
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	if config.Bucket == "" || config.Region == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("s3 storage needs a bucket, region, access key ID and secret access key")
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "https" && endpoint.Scheme != "http") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+	return &S3Storage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, "", nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a presigned GET link to key that works until expires has passed
+func (s *S3Storage) SignedURL(key string, expires time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	if expires <= 0 || expires > maxSignedExpires {
+		return "", fmt.Errorf("signed url expiry must be between 1s and %s", maxSignedExpires)
+	}
+	return s.presign(http.MethodGet, key, expires), nil
+}
+
+// presign builds a link carrying its signature in the query string
+func (s *S3Storage) presign(method, key string, expires time.Duration) string {
+	object := s.objectURL(key)
+	now := s.now().UTC()
+	scope := s.scope(now)
+
+	query := url.Values{
+		"X-Amz-Algorithm":     {amzAlgorithm},
+		"X-Amz-Credential":    {s.config.AccessKeyID + "/" + scope},
+		"X-Amz-Date":          {now.Format(amzDateFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(expires.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+	canonicalRequest := strings.Join([]string{
+		method,
+		uriEncodePath(object.Path),
+		canonicalQuery,
+		"host:" + object.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	signature := s.sign(now, scope, canonicalRequest)
+	return object.Scheme + "://" + object.Host + uriEncodePath(object.Path) + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+// do sends a request for key signed in the Authorization header. A 404
+// answers ErrNotFound and any other failure status an error.
+func (s *S3Storage) do(ctx context.Context, method, key, contentType string, body []byte) (*http.Response, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	object := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, object.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	scope := s.scope(now)
+	payloadHash := sha256Hex(body)
+	headers := map[string]string{
+		"host":                 object.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+		if name != "host" {
+			req.Header.Set(name, headers[name])
+		}
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		uriEncodePath(object.Path),
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		amzAlgorithm, s.config.AccessKeyID, scope, signedHeaders, s.sign(now, scope, canonicalRequest)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", method, key, resp.Status, detail)
+	}
+	return resp, nil
+}
+
+func (s *S3Storage) objectURL(key string) *url.URL {
+	object := *s.endpoint
+	if s.config.PathStyle {
+		object.Path = "/" + s.config.Bucket + "/" + key
+	} else {
+		object.Host = s.config.Bucket + "." + object.Host
+		object.Path = "/" + key
+	}
+	return &object
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.config.Region + "/s3/aws4_request"
+}
+
+// sign derives the day's signing key and signs the canonical request with it
+func (s *S3Storage) sign(now time.Time, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{amzAlgorithm, now.Format(amzDateFormat), scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + s.config.SecretAccessKey)
+	for _, part := range []string{now.Format("20060102"), s.config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// uriEncodePath escapes a path the way Signature Version 4 expects: every
+// byte but unreserved characters and slashes
+func uriEncodePath(path string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || strings.IndexByte("-_.~/", c) >= 0 {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareLabelsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not found", http.StatusNotFound)
+	})
+	router.Use(Middleware)
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("/things/{id}", "GET", "404"))
+	for _, id := range []string{"1", "2", "3"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/things/"+id, nil))
+	}
+
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("/things/{id}", "GET", "404")) - before; got != 3 {
+		t.Errorf("Expected 3 requests under the route template, got %v", got)
+	}
+}
+
+func TestHandlerServesMetrics(t *testing.T) {
+	UsersCreated.Inc()
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+	for _, name := range []string{"skillswap_users_created_total", "skillswap_bookings_completed_total", "skillswap_reviews_posted_total"} {
+		if !strings.Contains(rr.Body.String(), name) {
+			t.Errorf("Expected %s in metrics output", name)
+		}
+	}
+}
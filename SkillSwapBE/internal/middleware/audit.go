@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+	"skillswap/internal/audit"
+	"skillswap/internal/logging"
+)
+
+// AuditContext attaches the request ID and client IP to the request context
+// for audit log entries. It runs after RequestID. EnsureUserExists adds the acting user.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := audit.WithActor(r.Context(), audit.Actor{
+			RequestID: logging.RequestID(r.Context()),
+			IP:        ClientIP(r),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
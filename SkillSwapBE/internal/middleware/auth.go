@@ -2,22 +2,66 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
+	"skillswap/internal/apierror"
+	"skillswap/internal/metrics"
+
 	"github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
 )
 
 type CustomClaims struct {
 	Sub   string `json:"sub"`
 	Email string `json:"email"`
 	Name  string `json:"name"`
+
+	// Provider is the name of the identity provider that issued the token
+	Provider string `json:"-"`
+	// Roles are read from the provider's roles claim, if it has one
+	Roles []string `json:"-"`
+
+	rolesClaim string
+}
+
+// UnmarshalJSON decodes the standard claims plus the provider's roles claim,
+// whose (usually namespaced) name is only known at runtime
+func (c *CustomClaims) UnmarshalJSON(data []byte) error {
+	type standardClaims CustomClaims
+	if err := json.Unmarshal(data, (*standardClaims)(c)); err != nil {
+		return err
+	}
+	if c.rolesClaim == "" {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	rolesJSON, ok := raw[c.rolesClaim]
+	if !ok {
+		return nil
+	}
+
+	// Accept either a list of roles or a single role string
+	if err := json.Unmarshal(rolesJSON, &c.Roles); err != nil {
+		var role string
+		if err := json.Unmarshal(rolesJSON, &role); err != nil {
+			return fmt.Errorf("invalid %s claim: %w", c.rolesClaim, err)
+		}
+		c.Roles = []string{role}
+	}
+	return nil
 }
 
 func (c *CustomClaims) Validate(ctx context.Context) error {
@@ -25,38 +69,178 @@ func (c *CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
-func EnsureValidToken(domain, audience string) func(next http.Handler) http.Handler {
-	issuerURL, err := url.Parse("https://" + domain + "/")
+// IdentityProvider is a trusted OIDC token issuer
+type IdentityProvider struct {
+	Name     string `json:"name"`     // Stable identifier stored with each linked identity
+	Issuer   string `json:"issuer"`   // Expected "iss" claim, e.g. https://foxcroft.uk.auth0.com/
+	Audience string `json:"audience"` // Expected "aud" claim
+	JWKSURL  string `json:"jwks_url"` // Optional, discovered from the issuer when empty
+
+	// RolesClaim names the claim carrying SkillSwap roles. Leave it empty for
+	// providers that shouldn't be trusted to grant roles.
+	RolesClaim string `json:"roles_claim"`
+
+	// KeyFunc overrides JWKS lookup, e.g. for the local dev issuer
+	KeyFunc func(ctx context.Context) (interface{}, error) `json:"-"`
+}
+
+// Auth0RolesClaim is the namespaced claim an Auth0 Action adds with the user's roles
+const Auth0RolesClaim = "https://skillswap.softfox.com/roles"
+
+// Auth0Provider returns the provider for an Auth0 tenant
+func Auth0Provider(domain, audience string) IdentityProvider {
+	return IdentityProvider{
+		Name:       "auth0",
+		Issuer:     "https://" + domain + "/",
+		Audience:   audience,
+		RolesClaim: Auth0RolesClaim,
+	}
+}
+
+// ParseProviders reads additional providers from a JSON array such as
+// [{"name": "corp", "issuer": "https://sso.example.com/", "audience": "skillswap"}]
+func ParseProviders(raw string) ([]IdentityProvider, error) {
+	var providers []IdentityProvider
+	if err := json.Unmarshal([]byte(raw), &providers); err != nil {
+		return nil, fmt.Errorf("invalid identity provider configuration: %w", err)
+	}
+
+	for _, p := range providers {
+		if p.Name == "" || p.Issuer == "" || p.Audience == "" {
+			return nil, fmt.Errorf("identity provider %q needs a name, issuer and audience", p.Name)
+		}
+	}
+	return providers, nil
+}
+
+// TokenValidator checks bearer tokens against every trusted identity provider,
+// picking the provider by the token's issuer
+type TokenValidator struct {
+	validators map[string]*validator.Validator
+	keyFuncs   map[string]func(ctx context.Context) (interface{}, error) // By provider name
+}
+
+// NewTokenValidator sets up signature and claim validation for each provider
+func NewTokenValidator(providers ...IdentityProvider) (*TokenValidator, error) {
+	v := &TokenValidator{
+		validators: make(map[string]*validator.Validator, len(providers)),
+		keyFuncs:   make(map[string]func(ctx context.Context) (interface{}, error), len(providers)),
+	}
+
+	for _, p := range providers {
+		if _, exists := v.validators[p.Issuer]; exists {
+			return nil, fmt.Errorf("issuer %s is configured more than once", p.Issuer)
+		}
+
+		keyFunc := p.KeyFunc
+		if keyFunc == nil {
+			issuerURL, err := url.Parse(p.Issuer)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the issuer url for %s: %w", p.Name, err)
+			}
+
+			var opts []interface{}
+			if p.JWKSURL != "" {
+				jwksURL, err := url.Parse(p.JWKSURL)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse the jwks url for %s: %w", p.Name, err)
+				}
+				opts = append(opts, jwks.WithCustomJWKSURI(jwksURL))
+			}
+			keyFunc = countFailures(p.Name, jwks.NewCachingProvider(issuerURL, 5*time.Minute, opts...).KeyFunc)
+		}
+
+		name, rolesClaim := p.Name, p.RolesClaim
+		providerValidator, err := validator.New(
+			keyFunc,
+			validator.RS256,
+			p.Issuer,
+			[]string{p.Audience},
+			validator.WithCustomClaims(
+				func() validator.CustomClaims {
+					return &CustomClaims{Provider: name, rolesClaim: rolesClaim}
+				},
+			),
+			validator.WithAllowedClockSkew(time.Minute),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up the validator for %s: %w", p.Name, err)
+		}
+		v.validators[p.Issuer] = providerValidator
+		v.keyFuncs[p.Name] = keyFunc
+	}
+
+	return v, nil
+}
+
+// countFailures wraps a JWKS key lookup to count the times it fails
+func countFailures(provider string, keyFunc func(ctx context.Context) (interface{}, error)) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		key, err := keyFunc(ctx)
+		if err != nil {
+			metrics.JWKSFetchFailures.WithLabelValues(provider).Inc()
+		}
+		return key, err
+	}
+}
+
+// ProviderNames lists the trusted identity providers in name order
+func (v *TokenValidator) ProviderNames() []string {
+	names := make([]string, 0, len(v.keyFuncs))
+	for name := range v.keyFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckSigningKeys fetches a provider's signing keys, which are served from
+// the JWKS cache while it's fresh, so calling it also warms the cache
+func (v *TokenValidator) CheckSigningKeys(ctx context.Context, provider string) error {
+	keyFunc, ok := v.keyFuncs[provider]
+	if !ok {
+		return fmt.Errorf("unknown identity provider %q", provider)
+	}
+	_, err := keyFunc(ctx)
+	return err
+}
+
+// ValidateToken validates a token with the validator for its issuer
+func (v *TokenValidator) ValidateToken(ctx context.Context, token string) (interface{}, error) {
+	parsed, err := jwt.ParseSigned(token)
 	if err != nil {
-		log.Fatalf("Failed to parse the issuer url: %v", err)
-	}
-
-	provider := jwks.NewCachingProvider(issuerURL, 5*time.Minute)
-	keyFunc := provider.KeyFunc
-	
-	validator, err := validator.New(
-		keyFunc,
-		validator.RS256,
-		issuerURL.String(),
-		[]string{audience},
-		validator.WithCustomClaims(
-			func() validator.CustomClaims {
-				return &CustomClaims{}
-			},
-		),
-		validator.WithAllowedClockSkew(time.Minute),
-	)
+		return nil, fmt.Errorf("could not parse the token: %w", err)
+	}
+
+	// The issuer is only used to pick a validator, which then checks the signature
+	var unverified jwt.Claims
+	if err := parsed.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return nil, fmt.Errorf("could not read token claims: %w", err)
+	}
+
+	providerValidator, ok := v.validators[unverified.Issuer]
+	if !ok {
+		return nil, fmt.Errorf("token issuer %q is not trusted", unverified.Issuer)
+	}
+	return providerValidator.ValidateToken(ctx, token)
+}
+
+// Identity validates a token and returns its identity claims
+func (v *TokenValidator) Identity(ctx context.Context, token string) (*CustomClaims, error) {
+	validated, err := v.ValidateToken(ctx, token)
 	if err != nil {
-		log.Fatalf("Failed to set up the validator: %v", err)
+		return nil, err
 	}
+	return customClaims(validated)
+}
 
+// Middleware rejects requests without a valid bearer token from a trusted provider
+func (v *TokenValidator) Middleware() func(next http.Handler) http.Handler {
 	middleware := jwtmiddleware.New(
-		validator.ValidateToken,
+		v.ValidateToken,
 		jwtmiddleware.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
-			log.Printf("Encountered error while validating JWT: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(`{"error": "unauthorized", "message": "Invalid or missing token"}`))
+			slog.InfoContext(r.Context(), "Rejected bearer token", "error", err)
+			apierror.Write(w, r, http.StatusUnauthorized, "Invalid or missing token")
 		}),
 		jwtmiddleware.WithTokenExtractor(func(r *http.Request) (string, error) {
 			authHeader := r.Header.Get("Authorization")
@@ -78,7 +262,11 @@ func EnsureValidToken(domain, audience string) func(next http.Handler) http.Hand
 
 // Helper function to extract user info from JWT token
 func GetUserFromContext(ctx context.Context) (*CustomClaims, error) {
-	claims, ok := ctx.Value(jwtmiddleware.ContextKey{}).(*validator.ValidatedClaims)
+	return customClaims(ctx.Value(jwtmiddleware.ContextKey{}))
+}
+
+func customClaims(value interface{}) (*CustomClaims, error) {
+	claims, ok := value.(*validator.ValidatedClaims)
 	if !ok {
 		return nil, fmt.Errorf("no claims found in context")
 	}
@@ -89,4 +277,4 @@ func GetUserFromContext(ctx context.Context) (*CustomClaims, error) {
 	}
 
 	return customClaims, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"skillswap/internal/devauth"
+	"skillswap/internal/metrics"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newIssuer(t *testing.T, issuerURL string) *devauth.Issuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+	issuer, err := devauth.NewIssuer(key, issuerURL, "skillswapapi")
+	if err != nil {
+		t.Fatalf("failed to create issuer: %v", err)
+	}
+	return issuer
+}
+
+func provider(name string, issuer *devauth.Issuer) IdentityProvider {
+	return IdentityProvider{Name: name, Issuer: issuer.Issuer(), Audience: issuer.Audience(), KeyFunc: issuer.KeyFunc}
+}
+
+func TestTokenValidatorPicksProviderByIssuer(t *testing.T) {
+	auth0 := newIssuer(t, "https://tenant.auth0.test/")
+	corp := newIssuer(t, "https://sso.corp.test/")
+	untrusted := newIssuer(t, "https://evil.test/")
+
+	tokenValidator, err := NewTokenValidator(provider("auth0", auth0), provider("corp", corp))
+	if err != nil {
+		t.Fatalf("NewTokenValidator returned error: %v", err)
+	}
+
+	for name, issuer := range map[string]*devauth.Issuer{"auth0": auth0, "corp": corp} {
+		token, _ := issuer.Mint("user-1", "user@example.com", "User", time.Hour)
+		claims, err := tokenValidator.Identity(context.Background(), token)
+		if err != nil {
+			t.Fatalf("Expected %s token to validate, got %v", name, err)
+		}
+		if claims.Provider != name || claims.Sub != "user-1" {
+			t.Errorf("Expected %s identity for user-1, got %s identity for %s", name, claims.Provider, claims.Sub)
+		}
+	}
+
+	token, _ := untrusted.Mint("user-1", "", "", time.Hour)
+	if _, err := tokenValidator.Identity(context.Background(), token); err == nil {
+		t.Error("Expected token from an untrusted issuer to be rejected")
+	}
+}
+
+func TestTokenValidatorRejectsSpoofedIssuer(t *testing.T) {
+	trusted := newIssuer(t, "https://tenant.auth0.test/")
+	// Same "iss" claim, different signing key
+	spoofed := newIssuer(t, "https://tenant.auth0.test/")
+
+	tokenValidator, err := NewTokenValidator(provider("auth0", trusted))
+	if err != nil {
+		t.Fatalf("NewTokenValidator returned error: %v", err)
+	}
+
+	token, _ := spoofed.Mint("user-1", "", "", time.Hour)
+	if _, err := tokenValidator.Identity(context.Background(), token); err == nil {
+		t.Error("Expected token signed with the wrong key to be rejected")
+	}
+}
+
+func TestParseProviders(t *testing.T) {
+	providers, err := ParseProviders(`[{"name": "corp", "issuer": "https://sso.corp.test/", "audience": "skillswap", "jwks_url": "https://sso.corp.test/keys"}]`)
+	if err != nil {
+		t.Fatalf("ParseProviders returned error: %v", err)
+	}
+	if len(providers) != 1 || providers[0].JWKSURL != "https://sso.corp.test/keys" {
+		t.Errorf("Unexpected providers: %+v", providers)
+	}
+
+	if _, err := ParseProviders(`[{"name": "corp"}]`); err == nil {
+		t.Error("Expected error for provider without issuer and audience")
+	}
+}
+
+func TestRolesOnlyTrustedFromConfiguredClaim(t *testing.T) {
+	withRoles := newIssuer(t, "https://tenant.auth0.test/")
+	withoutRoles := newIssuer(t, "https://sso.corp.test/")
+
+	trusted := provider("auth0", withRoles)
+	trusted.RolesClaim = devauth.RolesClaim
+	tokenValidator, err := NewTokenValidator(trusted, provider("corp", withoutRoles))
+	if err != nil {
+		t.Fatalf("NewTokenValidator returned error: %v", err)
+	}
+
+	token, _ := withRoles.Mint("user-1", "", "", time.Hour, "admin")
+	claims, err := tokenValidator.Identity(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Identity returned error: %v", err)
+	}
+	if len(claims.Roles) != 1 || claims.Roles[0] != "admin" {
+		t.Errorf("Expected roles [admin], got %v", claims.Roles)
+	}
+
+	token, _ = withoutRoles.Mint("user-2", "", "", time.Hour, "admin")
+	claims, err = tokenValidator.Identity(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Identity returned error: %v", err)
+	}
+	if len(claims.Roles) != 0 {
+		t.Errorf("Expected roles from a provider without a roles claim to be ignored, got %v", claims.Roles)
+	}
+}
+
+func TestJWKSFetchFailuresAreCounted(t *testing.T) {
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+
+	issuer := newIssuer(t, broken.URL+"/")
+	tokenValidator, err := NewTokenValidator(IdentityProvider{
+		Name:     "flaky",
+		Issuer:   issuer.Issuer(),
+		Audience: issuer.Audience(),
+		JWKSURL:  broken.URL + "/.well-known/jwks.json",
+	})
+	if err != nil {
+		t.Fatalf("NewTokenValidator returned error: %v", err)
+	}
+
+	before := promtestutil.ToFloat64(metrics.JWKSFetchFailures.WithLabelValues("flaky"))
+	token, _ := issuer.Mint("user-1", "", "", time.Hour)
+	if _, err := tokenValidator.Identity(context.Background(), token); err == nil {
+		t.Fatal("Expected validation to fail without signing keys")
+	}
+	if got := promtestutil.ToFloat64(metrics.JWKSFetchFailures.WithLabelValues("flaky")) - before; got != 1 {
+		t.Errorf("Expected 1 JWKS fetch failure, got %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"skillswap/internal/logging"
+)
+
+func TestRequestIDAcceptsOrGenerates(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if seen != "abc-123" || rr.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Errorf("Expected the caller's request ID to be kept, got %q and %q", seen, rr.Header().Get(RequestIDHeader))
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if seen == "" || seen == "bad id\nwith newline" || rr.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("Expected a generated request ID, got %q", seen)
+	}
+}
+
+func TestLoggingMiddlewareWritesAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := logging.New(&buf, "info", "json")
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	handler := RequestID(LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUserID(r.Context(), "user-1")
+		http.Error(w, "Not found", http.StatusNotFound)
+	})))
+
+	req := httptest.NewRequest("GET", "/api/v1/things?secret=1", nil)
+	req.Header.Set(RequestIDHeader, "req-9")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one JSON access log line, got %q", buf.String())
+	}
+	if line["status"] != float64(http.StatusNotFound) || line["path"] != "/api/v1/things" {
+		t.Errorf("Expected status and path in access log, got %v", line)
+	}
+	if line["request_id"] != "req-9" || line["user_id"] != "user-1" {
+		t.Errorf("Expected request and user IDs in access log, got %v", line)
+	}
+	if line["bytes"] == float64(0) {
+		t.Errorf("Expected response size in access log, got %v", line)
+	}
+}
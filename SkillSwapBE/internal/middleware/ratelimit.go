@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimits are the request budgets for each kind of route
+type RateLimits struct {
+	Public    ratelimit.Limit // Per client IP on public routes
+	Protected ratelimit.Limit // Per user on protected and admin routes
+	Writes    ratelimit.Limit // Per user on POST, PUT, PATCH and DELETE, on top of Protected
+}
+
+// DefaultRateLimits apply unless overridden by configuration
+var DefaultRateLimits = RateLimits{
+	Public:    ratelimit.Limit{Requests: 60, Window: time.Minute},
+	Protected: ratelimit.Limit{Requests: 120, Window: time.Minute},
+	Writes:    ratelimit.Limit{Requests: 20, Window: time.Minute},
+}
+
+// RateLimiter enforces RateLimits, counting requests in store
+type RateLimiter struct {
+	store  ratelimit.Store
+	limits RateLimits
+}
+
+func NewRateLimiter(store ratelimit.Store, limits RateLimits) *RateLimiter {
+	return &RateLimiter{store: store, limits: limits}
+}
+
+// PerIP limits public routes by client IP
+func (l *RateLimiter) PerIP() func(http.Handler) http.Handler {
+	return l.limit("public", l.limits.Public, ClientIP, nil)
+}
+
+// PerUser limits authenticated routes by the token's identity. It runs after
+// token validation but before EnsureUserExists, so throttled requests never
+// reach the database.
+func (l *RateLimiter) PerUser() func(http.Handler) http.Handler {
+	return l.limit("user", l.limits.Protected, tokenIdentity, nil)
+}
+
+// Writes is a stricter per-user budget for requests that change data, such as
+// bookings, reviews and messages. Reads pass through untouched.
+func (l *RateLimiter) Writes() func(http.Handler) http.Handler {
+	return l.limit("write", l.limits.Writes, tokenIdentity, isWrite)
+}
+
+func isWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// tokenIdentity keys a request by its provider and subject, falling back to the client IP
+func tokenIdentity(r *http.Request) string {
+	if claims, err := GetUserFromContext(r.Context()); err == nil {
+		return claims.Provider + "|" + claims.Sub
+	}
+	return ClientIP(r)
+}
+
+// limit counts requests matching applies against bucket, keyed by key, and
+// answers 429 with Retry-After once the limit is used up. RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers are set on every response.
+func (l *RateLimiter) limit(bucket string, limit ratelimit.Limit, key func(*http.Request) string, applies func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if applies != nil && !applies(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := time.Now()
+			result, err := l.store.Take(r.Context(), bucket+":"+key(r), limit, now)
+			if err != nil {
+				// Fail open: a rate limit outage shouldn't take the API down with it
+				slog.WarnContext(r.Context(), "Rate limit check failed, allowing request", "bucket", bucket, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reset := int(math.Ceil(result.Reset.Sub(now).Seconds()))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(reset))
+
+			if !result.Allowed {
+				slog.InfoContext(r.Context(), "Rate limit exceeded", "bucket", bucket, "limit", limit.String())
+				w.Header().Set("Retry-After", strconv.Itoa(reset))
+				apierror.Write(w, r, http.StatusTooManyRequests, "Too many requests, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// trustForwardedFor is set when the API runs behind a proxy, such as the
+// Heroku router, that appends the client's address to X-Forwarded-For
+var trustForwardedFor bool
+
+// TrustForwardedFor makes ClientIP read the client address from the last
+// X-Forwarded-For entry. Only enable it behind a proxy that sets the header,
+// or clients can pick their own address.
+func TrustForwardedFor(trust bool) {
+	trustForwardedFor = trust
+}
+
+// ClientIP returns the address of the client that made the request
+func ClientIP(r *http.Request) string {
+	if trustForwardedFor {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			parts := strings.Split(values[len(values)-1], ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"skillswap/internal/ratelimit"
+)
+
+func TestRateLimiterRefusesOnceLimitIsUsed(t *testing.T) {
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), RateLimits{
+		Public: ratelimit.Limit{Requests: 2, Window: time.Minute},
+	})
+	handler := limiter.PerIP()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/public/skills", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		rr := request("203.0.113.7:4000")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, http.StatusOK, rr.Code)
+		}
+		if rr.Header().Get("RateLimit-Limit") != "2" || rr.Header().Get("RateLimit-Remaining") != remaining {
+			t.Errorf("Request %d: expected limit 2 with %s remaining, got %s and %s", i+1, remaining,
+				rr.Header().Get("RateLimit-Limit"), rr.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	rr := request("203.0.113.7:4001")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" || rr.Header().Get("Retry-After") != rr.Header().Get("RateLimit-Reset") {
+		t.Errorf("Expected Retry-After to match RateLimit-Reset, got %q and %q",
+			rr.Header().Get("Retry-After"), rr.Header().Get("RateLimit-Reset"))
+	}
+
+	if rr := request("198.51.100.1:4000"); rr.Code != http.StatusOK {
+		t.Errorf("Expected another client to be allowed, got %d", rr.Code)
+	}
+}
+
+func TestRateLimiterWritesOnlyCountsWrites(t *testing.T) {
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), RateLimits{
+		Writes: ratelimit.Limit{Requests: 1, Window: time.Minute},
+	})
+	handler := limiter.Writes()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := map[string][]int{}
+	for _, method := range []string{"GET", "PUT", "GET", "POST"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, "/api/v1/protected/profile", nil))
+		codes[method] = append(codes[method], rr.Code)
+	}
+
+	if codes["GET"][0] != http.StatusOK || codes["GET"][1] != http.StatusOK {
+		t.Errorf("Expected reads to pass through, got %v", codes["GET"])
+	}
+	if codes["PUT"][0] != http.StatusOK || codes["POST"][0] != http.StatusTooManyRequests {
+		t.Errorf("Expected the second write to be refused, got PUT %v and POST %v", codes["PUT"], codes["POST"])
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit, time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("database unavailable")
+}
+
+func TestRateLimiterFailsOpen(t *testing.T) {
+	limiter := NewRateLimiter(failingStore{}, DefaultRateLimits)
+	handler := limiter.PerIP()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/skills", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the request through when the store fails, got %d", rr.Code)
+	}
+}
+
+func TestClientIPTrustsForwardedForOnlyWhenEnabled(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.5:3000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9, 203.0.113.7")
+
+	if ip := ClientIP(req); ip != "10.0.0.5" {
+		t.Errorf("Expected the connecting address, got %s", ip)
+	}
+
+	TrustForwardedFor(true)
+	defer TrustForwardedFor(false)
+	// The proxy appends the address it saw, so earlier entries may be spoofed
+	if ip := ClientIP(req); ip != "203.0.113.7" {
+		t.Errorf("Expected the address the proxy appended, got %s", ip)
+	}
+}
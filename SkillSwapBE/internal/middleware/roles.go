@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/models"
+)
+
+// EffectiveRole returns the highest of the role stored on the user and any
+// role granted by the token's roles claim
+func EffectiveRole(r *http.Request) models.UserRole {
+	role := models.RoleUser
+	if user, ok := r.Context().Value("user").(*models.User); ok && user.Role.IsValid() {
+		role = user.Role
+	}
+
+	if claims, err := GetUserFromContext(r.Context()); err == nil {
+		for _, claimed := range claims.Roles {
+			if granted := models.UserRole(claimed); granted.Includes(role) {
+				role = granted
+			}
+		}
+	}
+
+	return role
+}
+
+// RequireRole only lets through users holding at least the required role.
+// It must run after EnsureUserExists.
+func RequireRole(required models.UserRole) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !EffectiveRole(r).Includes(required) {
+				apierror.Write(w, r, http.StatusForbidden, "Forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
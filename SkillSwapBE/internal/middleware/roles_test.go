@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"skillswap/internal/models"
+
+	"github.com/auth0/go-jwt-middleware/v2"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+func requestWithRoles(stored models.UserRole, claimed ...string) *http.Request {
+	ctx := context.WithValue(context.Background(), "user", &models.User{Role: stored})
+	ctx = context.WithValue(ctx, jwtmiddleware.ContextKey{}, &validator.ValidatedClaims{
+		CustomClaims: &CustomClaims{Roles: claimed},
+	})
+	return httptest.NewRequest("GET", "/api/v1/admin/users", nil).WithContext(ctx)
+}
+
+func TestEffectiveRole(t *testing.T) {
+	tests := []struct {
+		stored  models.UserRole
+		claimed []string
+		want    models.UserRole
+	}{
+		{models.RoleUser, nil, models.RoleUser},
+		{"", nil, models.RoleUser},
+		{models.RoleAdmin, nil, models.RoleAdmin},
+		{models.RoleUser, []string{"moderator"}, models.RoleModerator},
+		{models.RoleAdmin, []string{"moderator"}, models.RoleAdmin},
+		{models.RoleUser, []string{"superuser", "admin"}, models.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		if got := EffectiveRole(requestWithRoles(tt.stored, tt.claimed...)); got != tt.want {
+			t.Errorf("EffectiveRole(stored %q, claimed %v) = %q, want %q", tt.stored, tt.claimed, got, tt.want)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	handler := RequireRole(models.RoleModerator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, requestWithRoles(models.RoleUser))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a regular user, got %d", http.StatusForbidden, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, requestWithRoles(models.RoleUser, "moderator"))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d for a moderator, got %d", http.StatusOK, rr.Code)
+	}
+}
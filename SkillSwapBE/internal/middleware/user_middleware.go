@@ -3,10 +3,15 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"skillswap/internal/apierror"
+	"skillswap/internal/audit"
+	"skillswap/internal/logging"
 	"skillswap/internal/services"
+	"time"
 )
 
-// EnsureUserExists middleware that creates a user if they don't exist
+// EnsureUserExists middleware that creates a user if they don't exist. The
+// user is cached for services.UserCacheTTL, so most requests skip the database.
 func EnsureUserExists() func(next http.Handler) http.Handler {
 	userService := services.NewUserService()
 	
@@ -15,19 +20,38 @@ func EnsureUserExists() func(next http.Handler) http.Handler {
 			// Get user claims from JWT
 			claims, err := GetUserFromContext(r.Context())
 			if err != nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				apierror.Write(w, r, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
-			// Get or create user in database
-			user, err := userService.GetOrCreateUser(claims.Sub, claims.Email, claims.Name)
+			// Get or create user in database, with any suspension blocking them
+			user, suspension, err := userService.GetLoginUser(r.Context(), claims.Provider, claims.Sub, claims.Email, claims.Name)
 			if err != nil {
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				apierror.Write(w, r, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+
+			// Block suspended users before they reach any handler
+			if suspension != nil {
+				message := "Your account has been permanently banned"
+				if !suspension.IsPermanent() {
+					message = "Your account is suspended until " + suspension.ExpiresAt.UTC().Format(time.RFC3339)
+				}
+				apierror.WriteError(w, r, http.StatusForbidden, apierror.Error{
+					Code:    "account_suspended",
+					Message: message,
+					Details: map[string]interface{}{
+						"reason":     suspension.Reason,
+						"expires_at": suspension.ExpiresAt,
+					},
+				})
 				return
 			}
 
 			// Add user to request context for handlers to use
 			ctx := context.WithValue(r.Context(), "user", user)
+			ctx = audit.WithUser(ctx, user.ID)
+			logging.SetUserID(ctx, user.ID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// AccountExport is everything SkillSwap stores about a user, returned by the
+// self-service data export
+type AccountExport struct {
+	ExportedAt      time.Time        `json:"exported_at"`
+	Profile         User             `json:"profile"`
+	Identities      []UserIdentity   `json:"identities"`
+	Skills          []Skill          `json:"skills"`
+	Bookings        []Booking        `json:"bookings"`
+	ReviewsGiven    []Review         `json:"reviews_given"`
+	ReviewsReceived []Review         `json:"reviews_received"`
+	ReportsFiled    []Report         `json:"reports_filed"`
+	Suspensions     []Suspension     `json:"suspensions"`
+	PointsHistory   []PointsEvent    `json:"points_history"`
+	Badges          []UserBadge      `json:"badges"`
+	UsernameHistory []UsernameChange `json:"username_history"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	Message             string    `json:"message"`
+}
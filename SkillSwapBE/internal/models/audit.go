@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog records a security-relevant or financial change. Rows are only
+// ever inserted; a database trigger rejects updates and deletes, apart from
+// audit.Redact blanking personal data when a user is anonymised.
+type AuditLog struct {
+	ID         string          `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ActorID    *string         `json:"actor_id" gorm:"type:uuid;index"` // Nil for system actions such as scheduled purges
+	Action     string          `json:"action" gorm:"not null;index"`    // e.g. "user.update", "review.hide"
+	EntityType string          `json:"entity_type" gorm:"not null;index:idx_audit_logs_entity"`
+	EntityID   string          `json:"entity_id" gorm:"not null;index:idx_audit_logs_entity"`
+	Changes    json.RawMessage `json:"changes" gorm:"type:jsonb"` // Field name to {"before", "after"}
+	RequestID  string          `json:"request_id"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+}
+
+type AuditLogFilter struct {
+	ActorID    string
+	EntityType string
+	EntityID   string
+	Action     string
+	Since      *time.Time
+	Until      *time.Time
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// UserBadge records a badge awarded to a user. The unique index means each
+// badge is held at most once, however often it is re-evaluated.
+type UserBadge struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string    `json:"user_id" gorm:"not null;type:uuid;uniqueIndex:idx_user_badges_user_badge"`
+	BadgeID   string    `json:"badge_id" gorm:"not null;uniqueIndex:idx_user_badges_user_badge"` // A badges.Definitions ID
+	AwardedAt time.Time `json:"awarded_at" gorm:"not null"`
+}
+
+// EarnedBadge is a badge shown on a user's profile
+type EarnedBadge struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AwardedAt   time.Time `json:"awarded_at"`
+}
+
+// BadgeProgress is how far a user is towards a badge
+type BadgeProgress struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Current     int        `json:"current"`
+	Target      int        `json:"target"`
+	AwardedAt   *time.Time `json:"awarded_at"` // Nil until the badge is earned
+}
+
+// PublicProfile is a user in the public directory with the badges they've earned
+type PublicProfile struct {
+	PublicUser
+	Badges []EarnedBadge `json:"badges"`
+}
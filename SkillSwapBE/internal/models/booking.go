@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 	"gorm.io/gorm"
 )
@@ -29,6 +30,9 @@ type Booking struct {
 	Notes        string         `json:"notes"`
 	StudentNotes string         `json:"student_notes"`
 	TeacherNotes string         `json:"teacher_notes"`
+	CancelReason string         `json:"cancel_reason,omitempty"`
+	RefundAmount float64        `json:"refund_amount" gorm:"default:0"`
+	RefundedAt   *time.Time     `json:"refunded_at,omitempty"`
 	
 	// Relationships
 	Reviews      []Review       `json:"reviews,omitempty" gorm:"foreignKey:BookingID"`
@@ -38,6 +42,16 @@ type Booking struct {
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// MarshalJSON shows the student and teacher as their public profiles
+func (b Booking) MarshalJSON() ([]byte, error) {
+	type booking Booking // Drops this method so Marshal doesn't recurse
+	return json.Marshal(struct {
+		booking
+		Student *PublicUser `json:"student,omitempty"`
+		Teacher *PublicUser `json:"teacher,omitempty"`
+	}{booking(b), publicRelation(b.Student), publicRelation(b.Teacher)})
+}
+
 type CreateBookingRequest struct {
 	SkillID     string    `json:"skill_id" binding:"required"`
 	ScheduledAt time.Time `json:"scheduled_at" binding:"required"`
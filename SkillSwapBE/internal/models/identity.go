@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity links a login from a trusted identity provider to a user.
+// One user can have several identities (e.g. Auth0 and a corporate OIDC login).
+type UserIdentity struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      string    `json:"user_id" gorm:"not null;type:uuid;index"`
+	Provider    string    `json:"provider" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"` // Configured provider name, e.g. "auth0"
+	Subject     string    `json:"subject" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`  // "sub" claim issued by the provider
+	ClaimedName *string   `json:"claimed_name,omitempty"`                                                    // "name" claim at the last login, nil until one is seen
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LinkIdentityRequest struct {
+	Token string `json:"token" binding:"required"` // Access token issued to the same person by another provider
+}
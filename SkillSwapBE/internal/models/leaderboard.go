@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// PointsEvent records points awarded to, or taken from, a user. User.Points
+// is the running total; the history lets leaderboards count points earned
+// within a window.
+type PointsEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID    string    `json:"user_id" gorm:"not null;type:uuid;index:idx_points_events_user_created"`
+	Points    int       `json:"points" gorm:"not null"`
+	Reason    string    `json:"reason" gorm:"not null"` // e.g. "booking.completed"
+	CreatedAt time.Time `json:"created_at" gorm:"index;index:idx_points_events_user_created"`
+}
+
+type LeaderboardBoard string
+
+const (
+	BoardPoints LeaderboardBoard = "points" // Points earned in the window
+	BoardRating LeaderboardBoard = "rating" // Teachers' average rating
+)
+
+type LeaderboardWindow string
+
+const (
+	WindowWeekly  LeaderboardWindow = "weekly"
+	WindowMonthly LeaderboardWindow = "monthly"
+	WindowAllTime LeaderboardWindow = "all_time"
+)
+
+// IsValid reports whether w is a known window
+func (w LeaderboardWindow) IsValid() bool {
+	return w == WindowWeekly || w == WindowMonthly || w == WindowAllTime
+}
+
+// Since returns the start of the window ending at now, or the zero time for
+// all time. Windows roll, so weekly is the last seven days.
+func (w LeaderboardWindow) Since(now time.Time) time.Time {
+	switch w {
+	case WindowWeekly:
+		return now.AddDate(0, 0, -7)
+	case WindowMonthly:
+		return now.AddDate(0, -1, 0)
+	}
+	return time.Time{}
+}
+
+const (
+	// DefaultLeaderboardSize is how many places a leaderboard shows unless asked
+	DefaultLeaderboardSize = 10
+	// MaxLeaderboardSize caps the places a request can ask for
+	MaxLeaderboardSize = 100
+	// DefaultMinReviews keeps teachers with a handful of reviews off the rating board
+	DefaultMinReviews = 3
+)
+
+// LeaderboardQuery selects a leaderboard. It is comparable so computed
+// leaderboards can be cached by query.
+type LeaderboardQuery struct {
+	Board      LeaderboardBoard
+	Window     LeaderboardWindow // Points board only
+	MinReviews int               // Rating board only
+	Category   string            // Users with an active skill listing in this category
+	Location   string            // Matched anywhere in the user's location, ignoring case
+	Size       int
+}
+
+type LeaderboardEntry struct {
+	Position int        `json:"position"`
+	User     PublicUser `json:"user"`
+	Score    float64    `json:"score"` // Points earned in the window, or the average rating
+}
+
+type Leaderboard struct {
+	Board       LeaderboardBoard   `json:"board"`
+	Window      LeaderboardWindow  `json:"window,omitempty"`
+	MinReviews  int                `json:"min_reviews,omitempty"`
+	Category    string             `json:"category,omitempty"`
+	Location    string             `json:"location,omitempty"`
+	GeneratedAt time.Time          `json:"generated_at"` // Leaderboards are cached, so may be a few minutes old
+	Entries     []LeaderboardEntry `json:"entries"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// MaxSkillImages is how many images a skill's gallery can hold
+const MaxSkillImages = 8
+
+// SkillImage is a picture in a skill listing's gallery, shown in upload order
+type SkillImage struct {
+	ID           string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	SkillID      string    `json:"skill_id" gorm:"not null;type:uuid;uniqueIndex:idx_skill_images_skill_key"`
+	URL          string    `json:"url" gorm:"not null"`
+	ThumbnailURL string    `json:"thumbnail_url" gorm:"not null"`
+	Key          string    `json:"-" gorm:"not null;uniqueIndex:idx_skill_images_skill_key"` // Storage key of the full image
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UploadedImage is where an upload can be fetched from once processed
+type UploadedImage struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PageKey methods order rows for cursor pagination by creation time, with the
+// ID breaking ties
+
+func (u User) PageKey() (time.Time, string)         { return u.CreatedAt, u.ID }
+func (s Skill) PageKey() (time.Time, string)        { return s.CreatedAt, s.ID }
+func (r Review) PageKey() (time.Time, string)       { return r.CreatedAt, r.ID }
+func (r Report) PageKey() (time.Time, string)       { return r.CreatedAt, r.ID }
+func (s Suspension) PageKey() (time.Time, string)   { return s.CreatedAt, s.ID }
+func (a AuditLog) PageKey() (time.Time, string)     { return a.CreatedAt, a.ID }
+func (i UserIdentity) PageKey() (time.Time, string) { return i.CreatedAt, i.ID }
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitBucket counts one client's requests in the current window, for
+// rate limits shared by every API instance
+type RateLimitBucket struct {
+	Key         string    `gorm:"primaryKey"` // Bucket name and client, e.g. "public:203.0.113.7"
+	WindowStart time.Time `gorm:"not null"`
+	Count       int       `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"` // End of the window, after which the row can be pruned
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+type ReportTargetType string
+
+const (
+	ReportTargetSkill  ReportTargetType = "skill"
+	ReportTargetUser   ReportTargetType = "user"
+	ReportTargetReview ReportTargetType = "review"
+)
+
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportActioned  ReportStatus = "actioned"  // A moderator acted on the content
+	ReportDismissed ReportStatus = "dismissed" // Nothing wrong with the content
+)
+
+// IsValid reports whether s is a known report status
+func (s ReportStatus) IsValid() bool {
+	return s == ReportOpen || s == ReportActioned || s == ReportDismissed
+}
+
+// Report flags a piece of content for the moderation queue. Reports filed by
+// the automatic pre-screen have no reporter and may hold the submitted content
+// back until a moderator dismisses them.
+type Report struct {
+	ID          string           `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	ReporterID  *string          `json:"reporter_id" gorm:"type:uuid;index"` // Nil for automatic reports
+	TargetType  ReportTargetType `json:"target_type" gorm:"not null;index:idx_reports_target"`
+	TargetID    string           `json:"target_id" gorm:"not null;type:uuid;index:idx_reports_target"`
+	Reason      string           `json:"reason" gorm:"not null"`
+	Details     string           `json:"details"`
+	Status      ReportStatus     `json:"status" gorm:"default:'open';index"`
+	HeldField   string           `json:"held_field,omitempty"`   // Field whose new value is held back, e.g. "bio"
+	HeldContent string           `json:"held_content,omitempty"` // Value applied if the report is dismissed
+	HeldOver    string           `json:"-"`                      // The field's value when it was held, which the held content may only replace
+	ResolvedBy  *string          `json:"resolved_by,omitempty" gorm:"type:uuid"`
+	ResolvedAt  *time.Time       `json:"resolved_at,omitempty"`
+	Resolution  string           `json:"resolution,omitempty"` // Moderator's note
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type CreateReportRequest struct {
+	Reason  string `json:"reason" binding:"required,max=200"`
+	Details string `json:"details" binding:"max=2000"`
+}
+
+type ResolveReportRequest struct {
+	Status     ReportStatus `json:"status" binding:"required,oneof=actioned dismissed"`
+	Resolution string       `json:"resolution" binding:"max=2000"`
+}
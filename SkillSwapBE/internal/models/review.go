@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 	"gorm.io/gorm"
 )
@@ -9,10 +10,12 @@ type Review struct {
 	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	ReviewerID  string         `json:"reviewer_id" gorm:"not null;type:uuid"`
 	RevieweeID  string         `json:"reviewee_id" gorm:"not null;type:uuid"`
-	BookingID   string         `json:"booking_id" gorm:"type:uuid"`
+	BookingID   *string        `json:"booking_id,omitempty" gorm:"type:uuid"` // Reviews aren't required to come from a booking
 	Rating      int            `json:"rating" gorm:"not null;check:rating >= 1 AND rating <= 5"`
 	Comment     string         `json:"comment"`
 	IsPublic    bool           `json:"is_public" gorm:"default:true"`
+	HiddenAt    *time.Time     `json:"hidden_at,omitempty"`                  // Set when a moderator hides the review
+	HiddenBy    *string        `json:"hidden_by,omitempty" gorm:"type:uuid"` // Moderator who hid the review
 	
 	// Relationships
 	Reviewer    User           `json:"reviewer" gorm:"foreignKey:ReviewerID"`
@@ -24,6 +27,16 @@ type Review struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// MarshalJSON shows the reviewer and reviewee as their public profiles
+func (r Review) MarshalJSON() ([]byte, error) {
+	type review Review // Drops this method so Marshal doesn't recurse
+	return json.Marshal(struct {
+		review
+		Reviewer *PublicUser `json:"reviewer,omitempty"`
+		Reviewee *PublicUser `json:"reviewee,omitempty"`
+	}{review(r), publicRelation(r.Reviewer), publicRelation(r.Reviewee)})
+}
+
 type CreateReviewRequest struct {
 	RevieweeID string `json:"reviewee_id" binding:"required"`
 	BookingID  string `json:"booking_id"`
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 	"gorm.io/gorm"
 )
@@ -9,14 +11,14 @@ type Skill struct {
 	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	Title       string         `json:"title" gorm:"not null"`
 	Description string         `json:"description"`
-	Category    string         `json:"category" gorm:"not null"`
+	Category    string         `json:"category" gorm:"not null"` // Name of the category, kept with the ID for display
+	CategoryID  *string        `json:"category_id,omitempty" gorm:"type:uuid;index"`
 	UserID      string         `json:"user_id" gorm:"not null;type:uuid"`
 	User        User           `json:"user" gorm:"foreignKey:UserID"`
 	Price       float64        `json:"price" gorm:"not null"`
 	Duration    int            `json:"duration" gorm:"not null"` // in minutes
 	Location    string         `json:"location"`
 	IsActive    bool           `json:"is_active" gorm:"default:true"`
-	Tags        string         `json:"tags"` // JSON array of tags
 	Level       string         `json:"level"` // Beginner, Intermediate, Advanced
 	MaxStudents int            `json:"max_students" gorm:"default:1"`
 	BookingCount int           `json:"booking_count" gorm:"default:0"`
@@ -25,12 +27,49 @@ type Skill struct {
 	
 	// Relationships
 	Bookings    []Booking      `json:"bookings,omitempty" gorm:"foreignKey:SkillID"`
+	Images      []SkillImage   `json:"images,omitempty" gorm:"foreignKey:SkillID;constraint:OnDelete:CASCADE"`
+	Tags        []Tag          `json:"-" gorm:"many2many:skill_tags;constraint:OnDelete:CASCADE"` // Shown as names by MarshalJSON
 	
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// MarshalJSON shows the teacher as their public profile and the tags by name
+func (s Skill) MarshalJSON() ([]byte, error) {
+	type skill Skill // Drops this method so Marshal doesn't recurse
+	tags := make([]string, len(s.Tags))
+	for i, tag := range s.Tags {
+		tags[i] = tag.Name
+	}
+	return json.Marshal(struct {
+		skill
+		User *PublicUser `json:"user,omitempty"`
+		Tags []string    `json:"tags"`
+	}{skill(s), publicRelation(s.User), tags})
+}
+
+// BeforeCreate files a new skill under the category its name or slug
+// matches, so "music " lands in "Music". Only admins add categories, so a
+// name that matches none is rejected with ErrUnknownCategory.
+func (s *Skill) BeforeCreate(tx *gorm.DB) error {
+	if s.CategoryID != nil || s.Category == "" {
+		return nil
+	}
+
+	var category Category
+	slug := CategorySlug(NormalizeCategoryName(s.Category))
+	err := tx.Session(&gorm.Session{NewDB: true}).First(&category, "slug = ?", slug).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUnknownCategory
+	}
+	if err != nil {
+		return err
+	}
+	s.Category, s.CategoryID = category.Name, &category.ID
+	return nil
+}
+
 type CreateSkillRequest struct {
 	Title       string  `json:"title" binding:"required"`
 	Description string  `json:"description"`
@@ -39,20 +78,21 @@ type CreateSkillRequest struct {
 	Duration    int     `json:"duration" binding:"required,min=15"`
 	Location    string  `json:"location"`
 	Level       string  `json:"level"`
-	MaxStudents int     `json:"max_students" binding:"min=1"`
-	Tags        string  `json:"tags"`
+	MaxStudents int     `json:"max_students" binding:"omitempty,min=1"`
+	Tags        []string `json:"tags" binding:"omitempty,max=10,dive,max=30"`
 }
 
+// UpdateSkillRequest fields left at their zero value are not changed
 type UpdateSkillRequest struct {
 	Title       string  `json:"title"`
 	Description string  `json:"description"`
 	Category    string  `json:"category"`
-	Price       float64 `json:"price" binding:"min=0"`
-	Duration    int     `json:"duration" binding:"min=15"`
+	Price       float64 `json:"price" binding:"omitempty,min=0"`
+	Duration    int     `json:"duration" binding:"omitempty,min=15"`
 	Location    string  `json:"location"`
 	Level       string  `json:"level"`
-	MaxStudents int     `json:"max_students" binding:"min=1"`
-	Tags        string  `json:"tags"`
+	MaxStudents int     `json:"max_students" binding:"omitempty,min=1"`
+	Tags        []string `json:"tags" binding:"omitempty,max=10,dive,max=30"`
 	IsActive    *bool   `json:"is_active"`
 }
 
@@ -62,9 +102,8 @@ type SkillSearchParams struct {
 	MaxPrice    float64 `json:"max_price,omitempty"`
 	MinPrice    float64 `json:"min_price,omitempty"`
 	Level       string  `json:"level,omitempty"`
+	Tag         string  `json:"tag,omitempty"`
 	Query       string  `json:"query,omitempty"`
 	UserID      string  `json:"user_id,omitempty"`
 	IsActive    *bool   `json:"is_active,omitempty"`
-	Limit       int     `json:"limit,omitempty"`
-	Offset      int     `json:"offset,omitempty"`
 }
\ No newline at end of file
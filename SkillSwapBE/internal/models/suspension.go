@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Suspension blocks a user from the protected API until it expires or is lifted.
+// A suspension without an expiry is a permanent ban.
+type Suspension struct {
+	ID          string     `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      string     `json:"user_id" gorm:"not null;type:uuid;index"`
+	ModeratorID string     `json:"moderator_id" gorm:"not null;type:uuid"` // Moderator or admin who suspended the user
+	Reason      string     `json:"reason" gorm:"not null"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LiftedAt    *time.Time `json:"lifted_at,omitempty"`
+	LiftedBy    *string    `json:"lifted_by,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IsPermanent reports whether the suspension is a ban with no expiry
+func (s *Suspension) IsPermanent() bool {
+	return s.ExpiresAt == nil
+}
+
+type CreateSuspensionRequest struct {
+	Reason    string     `json:"reason" binding:"required,max=500"`
+	ExpiresAt *time.Time `json:"expires_at"` // Omit for a permanent ban
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTagLength is the longest tag kept, in characters
+	MaxTagLength = 30
+	// MaxSkillTags is how many tags a skill listing can carry
+	MaxSkillTags = 10
+	// DefaultTagSuggestions and MaxTagSuggestions size tag autocomplete
+	DefaultTagSuggestions = 10
+	MaxTagSuggestions     = 50
+)
+
+// Category is a node in the managed category tree. Skills are filed under
+// one category, and a category's listings include those of its subcategories.
+type Category struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"not null"`
+	Slug      string    `json:"slug" gorm:"not null;uniqueIndex"` // From the name; what URLs and filters use
+	ParentID  *string   `json:"parent_id,omitempty" gorm:"type:uuid;index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Only declared so the foreign keys are created: a category in use can't be deleted
+	Children []Category `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:RESTRICT"`
+	Skills   []Skill    `json:"-" gorm:"foreignKey:CategoryID;constraint:OnDelete:RESTRICT"`
+}
+
+// CategoryNode is a category in the tree returned to clients, with the number
+// of active skill listings in it and its subcategories
+type CategoryNode struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Slug       string         `json:"slug"`
+	ParentID   *string        `json:"parent_id,omitempty"`
+	SkillCount int64          `json:"skill_count"`
+	Children   []CategoryNode `json:"children"`
+}
+
+// CategoryTree is every category, top-level categories first in name order
+type CategoryTree struct {
+	Categories []CategoryNode `json:"categories"`
+}
+
+// CategoryRequest creates a category, or replaces one's name and parent
+type CategoryRequest struct {
+	Name     string  `json:"name" binding:"required,max=50"`
+	ParentID *string `json:"parent_id" binding:"omitempty,uuid"` // Omit for a top-level category
+}
+
+// Tag labels skill listings. Names are normalized, so "Jazz " and "#jazz"
+// are the same tag.
+type Tag struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TagCount is a tag suggested by autocomplete, with how many active skill
+// listings carry it
+type TagCount struct {
+	Name       string `json:"name"`
+	SkillCount int64  `json:"skill_count"`
+}
+
+// TagSuggestions are the most used tags starting with a prefix
+type TagSuggestions struct {
+	Tags []TagCount `json:"tags"`
+}
+
+var (
+	// ErrCategorySlug is returned for a category name without a letter or digit
+	ErrCategorySlug = errors.New("must contain a letter or digit")
+	// ErrUnknownCategory is returned for a skill whose category matches none an
+	// admin has added
+	ErrUnknownCategory = errors.New("is not a category")
+)
+
+var (
+	spaces       = regexp.MustCompile(`\s+`)
+	nonSlugRunes = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// NormalizeCategoryName trims a category name and collapses its spacing
+func NormalizeCategoryName(name string) string {
+	return spaces.ReplaceAllString(strings.TrimSpace(name), " ")
+}
+
+// CategorySlug derives the slug for a category name, e.g. "Arts & Crafts"
+// becomes "arts-crafts". Names differing only in case, spacing or
+// punctuation share a slug.
+func CategorySlug(name string) string {
+	return strings.Trim(nonSlugRunes.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// NormalizeTag lower-cases a tag, drops a leading "#" and collapses spacing.
+// It returns "" for a tag that is empty or longer than MaxTagLength.
+func NormalizeTag(tag string) string {
+	tag = strings.TrimLeft(strings.TrimSpace(tag), "#")
+	tag = strings.ToLower(NormalizeCategoryName(tag))
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return ""
+	}
+	return tag
+}
+
+// NormalizeTags normalizes tags, dropping empty ones and duplicates and
+// keeping at most MaxSkillTags
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+		if len(normalized) == MaxSkillTags {
+			break
+		}
+	}
+	return normalized
+}
@@ -1,7 +1,6 @@
 package models
 
 import (
-	"math/rand"
 	"time"
 	"gorm.io/gorm"
 )
@@ -17,27 +16,167 @@ const (
 	Master        UserRank = "Master"
 )
 
+// IsValid reports whether r is a known rank
+func (r UserRank) IsValid() bool {
+	switch r {
+	case Novice, Beginner, Intermediate, Advanced, Expert, Master:
+		return true
+	}
+	return false
+}
+
+type UserRole string
+
+const (
+	RoleUser      UserRole = "user"
+	RoleModerator UserRole = "moderator"
+	RoleAdmin     UserRole = "admin"
+)
+
+// roleLevels orders roles so that each one includes the powers of those below it
+var roleLevels = map[UserRole]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// IsValid reports whether r is a known role
+func (r UserRole) IsValid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Includes reports whether r grants at least the powers of required
+func (r UserRole) Includes(required UserRole) bool {
+	return r.IsValid() && roleLevels[r] >= roleLevels[required]
+}
+
 type User struct {
 	ID          string         `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	Auth0ID     string         `json:"auth0_id" gorm:"uniqueIndex;not null"`
 	Username    string         `json:"username" gorm:"uniqueIndex;not null"`
 	Email       string         `json:"email" gorm:"-"` // Not stored in DB, populated from JWT claims
 	FullName    string         `json:"full_name"`
 	Location    string         `json:"location"`
 	Avatar      string         `json:"avatar"`
+	AvatarThumbnail string     `json:"avatar_thumbnail"` // Set for uploaded avatars only
 	Bio         string         `json:"bio"`
 	Points      int            `json:"points" gorm:"default:0"`
 	Rank        UserRank       `json:"rank" gorm:"default:'Novice'"`
+	Role        UserRole       `json:"role" gorm:"default:'user'"`
 	Rating      float64        `json:"rating" gorm:"default:0"`
 	ReviewCount int            `json:"review_count" gorm:"default:0"`
-	Skills      []Skill        `json:"skills" gorm:"foreignKey:UserID"`
-	ReviewsGiven []Review      `json:"reviews_given" gorm:"foreignKey:ReviewerID"`
-	ReviewsReceived []Review   `json:"reviews_received" gorm:"foreignKey:RevieweeID"`
+	Skills      []Skill        `json:"-" gorm:"foreignKey:UserID"` // Relations are served by their own endpoints, never embedded in a user
+	ReviewsGiven []Review      `json:"-" gorm:"foreignKey:ReviewerID"`
+	ReviewsReceived []Review   `json:"-" gorm:"foreignKey:RevieweeID"`
+	Identities  []UserIdentity `json:"-" gorm:"foreignKey:UserID"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"` // Set while a requested account deletion waits out its grace period
+	AnonymizedAt *time.Time    `json:"-"`                                     // Set once personal data has been removed
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// PublicUser is the part of a user anyone can see in the public directory
+type PublicUser struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	FullName    string    `json:"full_name"`
+	Location    string    `json:"location"`
+	Avatar      string    `json:"avatar"`
+	AvatarThumbnail string `json:"avatar_thumbnail,omitempty"`
+	Bio         string    `json:"bio"`
+	Points      int       `json:"points"`
+	Rank        UserRank  `json:"rank"`
+	Rating      float64   `json:"rating"`
+	ReviewCount int       `json:"review_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Public projects u for the public directory, leaving out contact details,
+// role and account state
+func (u User) Public() PublicUser {
+	return PublicUser{
+		ID:          u.ID,
+		Username:    u.Username,
+		FullName:    u.FullName,
+		Location:    u.Location,
+		Avatar:      u.Avatar,
+		AvatarThumbnail: u.AvatarThumbnail,
+		Bio:         u.Bio,
+		Points:      u.Points,
+		Rank:        u.Rank,
+		Rating:      u.Rating,
+		ReviewCount: u.ReviewCount,
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+// MemberUser is what a signed-in user sees of someone else: the public
+// profile, plus their role so moderators can be told apart
+type MemberUser struct {
+	PublicUser
+	Role UserRole `json:"role"`
+}
+
+// Member projects u for other signed-in users
+func (u User) Member() MemberUser {
+	return MemberUser{PublicUser: u.Public(), Role: u.Role}
+}
+
+// AccountUser is a user's view of their own account. Admins see other
+// accounts this way too, without the email, which is only known from the
+// user's own token.
+type AccountUser struct {
+	PublicUser
+	Email               string     `json:"email"`
+	Role                UserRole   `json:"role"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// Account projects u for its owner
+func (u User) Account() AccountUser {
+	return AccountUser{
+		PublicUser:          u.Public(),
+		Email:               u.Email,
+		Role:                u.Role,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		UpdatedAt:           u.UpdatedAt,
+	}
+}
+
+// publicRelation projects a user loaded as a relation of another row, e.g. a
+// review's reviewer, or returns nil when the relation wasn't loaded
+func publicRelation(u User) *PublicUser {
+	if u.ID == "" {
+		return nil
+	}
+	public := u.Public()
+	return &public
+}
+
+// UserSort orders the public directory
+type UserSort string
+
+const (
+	SortNewest UserSort = "newest"
+	SortRating UserSort = "rating"
+	SortPoints UserSort = "points"
+)
+
+// IsValid reports whether s is a known sort
+func (s UserSort) IsValid() bool {
+	return s == SortNewest || s == SortRating || s == SortPoints
+}
+
+// UserDirectoryFilter narrows the public directory. Empty fields match everyone.
+type UserDirectoryFilter struct {
+	Rank     UserRank
+	Location string // Matched anywhere in the user's location, ignoring case
+	Category string // Users with an active skill listing in this category
+	Sort     UserSort
+}
+
 type CreateUserRequest struct {
 	Username string `json:"username" binding:"required"`
 	FullName string `json:"full_name"`
@@ -47,10 +186,13 @@ type CreateUserRequest struct {
 
 type UpdateUserRequest struct {
 	Username string `json:"username"`
-	FullName string `json:"full_name"`
-	Location string `json:"location"`
-	Bio      string `json:"bio"`
-	Avatar   string `json:"avatar"`
+	FullName string `json:"full_name" binding:"max=100"`
+	Location string `json:"location" binding:"max=100"`
+	Bio      string `json:"bio" binding:"max=1000"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role UserRole `json:"role" binding:"required,oneof=user moderator admin"`
 }
 
 // CalculateRank determines user rank based on points
@@ -75,8 +217,3 @@ func (u *User) CalculateRank() UserRank {
 func (u *User) UpdateRank() {
 	u.Rank = u.CalculateRank()
 }
-
-// GenerateRandomInt generates a random integer for unique usernames
-func GenerateRandomInt() int {
-	return rand.Intn(9999) + 1000
-}
\ No newline at end of file
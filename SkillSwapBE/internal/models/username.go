@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// UsernameChange records a username a user gave up. Links to the old name
+// redirect to the user, and nobody else can claim it for a while.
+type UsernameChange struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      string    `json:"user_id" gorm:"not null;type:uuid;index"`
+	OldUsername string    `json:"old_username" gorm:"not null;index:idx_username_changes_old,expression:LOWER(old_username)"`
+	ChangedAt   time.Time `json:"changed_at" gorm:"not null"`
+}
+
+// UsernameAvailability answers whether the current user can take a username
+type UsernameAvailability struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"` // Why it isn't available
+}
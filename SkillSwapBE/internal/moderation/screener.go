@@ -0,0 +1,112 @@
+// Package moderation pre-screens user submitted text so that suspicious
+// content can be held for a moderator before it is shown to anyone.
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Screener decides whether a piece of text needs a moderator to look at it
+type Screener interface {
+	// Screen returns a reason when text should be held, or "" when it's fine
+	Screen(text string) string
+}
+
+// WordList holds text containing any of a set of words, ignoring case
+type WordList struct {
+	words []string
+}
+
+// NewWordList builds a word list screener, skipping blank entries
+func NewWordList(words ...string) *WordList {
+	list := &WordList{}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			list.words = append(list.words, word)
+		}
+	}
+	return list
+}
+
+func (l *WordList) Screen(text string) string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	})
+	for _, field := range fields {
+		for _, word := range l.words {
+			if field == word {
+				return fmt.Sprintf("contains blocked word %q", word)
+			}
+		}
+	}
+	return ""
+}
+
+// Rules holds text matching any of a set of regular expressions
+type Rules struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRules compiles regex rules, e.g. for phone numbers or links to other sites
+func NewRules(patterns ...string) (*Rules, error) {
+	rules := &Rules{}
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid moderation rule %q: %w", pattern, err)
+		}
+		rules.patterns = append(rules.patterns, re)
+	}
+	return rules, nil
+}
+
+func (r *Rules) Screen(text string) string {
+	for _, re := range r.patterns {
+		if re.MatchString(text) {
+			return fmt.Sprintf("matches rule %q", re.String())
+		}
+	}
+	return ""
+}
+
+// Chain runs several screeners, stopping at the first that holds the text
+type Chain []Screener
+
+func (c Chain) Screen(text string) string {
+	for _, screener := range c {
+		if reason := screener.Screen(text); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// FromConfig builds the screener configured by a comma-separated word list and
+// a newline-separated list of regex rules. Either may be empty.
+func FromConfig(words, rules string) (Screener, error) {
+	compiled, err := NewRules(strings.Split(rules, "\n")...)
+	if err != nil {
+		return nil, err
+	}
+	return Chain{NewWordList(strings.Split(words, ",")...), compiled}, nil
+}
+
+var screener Screener = Chain{}
+
+// SetScreener replaces the screener used by Screen, e.g. at startup
+func SetScreener(s Screener) {
+	screener = s
+}
+
+// Screen checks text with the configured screener
+func Screen(text string) string {
+	if text == "" {
+		return ""
+	}
+	return screener.Screen(text)
+}
@@ -0,0 +1,43 @@
+package moderation
+
+import "testing"
+
+func TestWordListMatchesWholeWords(t *testing.T) {
+	list := NewWordList("scam", " ", "Casino")
+
+	tests := []struct {
+		text string
+		held bool
+	}{
+		{"Totally not a SCAM!", true},
+		{"Visit my casino", true},
+		{"Learn to scamper up walls", false},
+		{"Guitar lessons", false},
+	}
+
+	for _, tt := range tests {
+		if held := list.Screen(tt.text) != ""; held != tt.held {
+			t.Errorf("Screen(%q): expected held=%v, got %v", tt.text, tt.held, held)
+		}
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	screener, err := FromConfig("scam", `https?://\S+`+"\n"+`\b\d{3}[- ]?\d{3}[- ]?\d{4}\b`)
+	if err != nil {
+		t.Fatalf("FromConfig returned error: %v", err)
+	}
+
+	for _, text := range []string{"a scam", "see http://example.com", "call 555-123-4567"} {
+		if screener.Screen(text) == "" {
+			t.Errorf("Expected %q to be held", text)
+		}
+	}
+	if reason := screener.Screen("Beginner guitar lessons"); reason != "" {
+		t.Errorf("Expected clean text to pass, got %q", reason)
+	}
+
+	if _, err := FromConfig("", "("); err == nil {
+		t.Error("Expected error for an invalid rule")
+	}
+}
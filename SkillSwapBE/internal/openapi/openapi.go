@@ -0,0 +1,59 @@
+// Package openapi serves the OpenAPI 3 description of the API and, outside
+// production, a Swagger UI page to browse it.
+//
+// openapi.yaml is the contract: the router tests check every response against
+// it, so a handler change that alters its JSON must update the spec too.
+package openapi
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+)
+
+// Spec is the OpenAPI document in YAML
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// SpecPath is where the document is served
+const SpecPath = "/openapi.yaml"
+
+// swaggerUIVersion pins the swagger-ui-dist release loaded from the CDN
+const swaggerUIVersion = "5.21.0"
+
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>SkillSwap API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: {{.SpecURL}}, dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`))
+
+// SpecHandler serves the OpenAPI document
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(Spec)
+}
+
+// DocsHandler serves Swagger UI pointed at SpecPath. It must not be
+// registered in production.
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	docsPage.Execute(w, struct {
+		Version string
+		SpecURL string
+	}{swaggerUIVersion, SpecPath})
+}
@@ -0,0 +1,228 @@
+// Package pagination pages list endpoints with opaque cursors. Rows are
+// ordered by (created_at, id), so a cursor is the key of the last row a client
+// saw and stays stable while rows are added or removed:
+//
+//	GET /api/v1/admin/users?limit=50
+//	{"data": [...], "next_cursor": "eyJ0Ijoi..."}
+//	GET /api/v1/admin/users?limit=50&cursor=eyJ0Ijoi...
+//
+// next_cursor is null on the last page.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultLimit is the page size when a request doesn't set one
+	DefaultLimit = 20
+	// MaxLimit caps the page size a request can ask for
+	MaxLimit = 100
+)
+
+var (
+	// ErrInvalidLimit is returned for a limit that isn't a positive number
+	ErrInvalidLimit = errors.New("limit must be a positive number")
+	// ErrInvalidCursor is returned for a cursor this package didn't issue
+	ErrInvalidCursor = errors.New("cursor is not valid")
+)
+
+// Keyed is a row that can be paged: its creation time and ID order it
+type Keyed interface {
+	PageKey() (time.Time, string)
+}
+
+// Order is the direction rows are listed in
+type Order int
+
+const (
+	// NewestFirst lists the most recently created rows first
+	NewestFirst Order = iota
+	// OldestFirst lists rows in the order they were created, e.g. a queue
+	OldestFirst
+)
+
+// Cursor is the key of the last row on the previous page
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+	Sort      string    `json:"s,omitempty"` // Column of a SortedScope, e.g. "rating"
+	Value     float64   `json:"v,omitempty"` // The row's value in that column
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a string returned by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// Page selects one page of a list
+type Page struct {
+	Limit int
+	After *Cursor // Nil for the first page
+}
+
+// First is the first page of the default size, for lists embedded in another response
+func First() Page {
+	return Page{Limit: DefaultLimit}
+}
+
+// FromRequest reads the limit and cursor query parameters. A limit above
+// MaxLimit is capped rather than rejected.
+func FromRequest(r *http.Request) (Page, error) {
+	query := r.URL.Query()
+	page := First()
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return Page{}, ErrInvalidLimit
+		}
+		page.Limit = min(limit, MaxLimit)
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := DecodeCursor(value)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = cursor
+	}
+	return page, nil
+}
+
+// Scope restricts a query to page. It fetches one row more than the limit so
+// NewList can tell whether another page follows. A cursor issued by
+// SortedScope fails the query with ErrInvalidCursor.
+func Scope(page Page, order Order) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		direction, comparison := "DESC", "<"
+		if order == OldestFirst {
+			direction, comparison = "ASC", ">"
+		}
+
+		if page.After != nil {
+			if page.After.Sort != "" {
+				db.AddError(ErrInvalidCursor)
+				return db
+			}
+			db = db.Where("(created_at, id) "+comparison+" (?, ?)", page.After.CreatedAt, page.After.ID)
+		}
+		return db.Order("created_at " + direction).Order("id " + direction).Limit(page.Limit + 1)
+	}
+}
+
+// SortedScope restricts a query to page, ordered by a numeric column, highest
+// first, with (created_at, id) breaking ties. A cursor issued for another
+// order fails the query with ErrInvalidCursor.
+func SortedScope(page Page, column string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if page.After != nil {
+			if page.After.Sort != column {
+				db.AddError(ErrInvalidCursor)
+				return db
+			}
+			db = db.Where("("+column+", created_at, id) < (?, ?, ?)", page.After.Value, page.After.CreatedAt, page.After.ID)
+		}
+		return db.Order(column + " DESC").Order("created_at DESC").Order("id DESC").Limit(page.Limit + 1)
+	}
+}
+
+// List is the envelope every list endpoint responds with
+type List[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"` // Nil on the last page
+}
+
+// NewList builds the response for rows fetched with Scope
+func NewList[T Keyed](rows []T, page Page) List[T] {
+	list := List[T]{Data: rows}
+	if list.Data == nil {
+		list.Data = []T{}
+	}
+	if len(list.Data) > page.Limit {
+		list.Data = list.Data[:page.Limit]
+		createdAt, id := list.Data[page.Limit-1].PageKey()
+		next := Cursor{CreatedAt: createdAt, ID: id}.Encode()
+		list.NextCursor = &next
+	}
+	return list
+}
+
+// NewSortedList builds the response for rows fetched with SortedScope, where
+// value reads a row's value in column
+func NewSortedList[T Keyed](rows []T, page Page, column string, value func(T) float64) List[T] {
+	list := NewList(rows, page)
+	if list.NextCursor != nil {
+		last := list.Data[len(list.Data)-1]
+		createdAt, id := last.PageKey()
+		next := Cursor{CreatedAt: createdAt, ID: id, Sort: column, Value: value(last)}.Encode()
+		list.NextCursor = &next
+	}
+	return list
+}
+
+// Map converts the rows on a page, e.g. to a public projection
+func Map[T, U any](list List[T], convert func(T) U) List[U] {
+	mapped := List[U]{Data: make([]U, 0, len(list.Data)), NextCursor: list.NextCursor}
+	for _, row := range list.Data {
+		mapped.Data = append(mapped.Data, convert(row))
+	}
+	return mapped
+}
+
+// Slice pages rows held in memory the same way Scope pages a query
+func Slice[T Keyed](rows []T, page Page, order Order) List[T] {
+	sorted := make([]T, len(rows))
+	copy(sorted, rows)
+	sort.Slice(sorted, func(i, j int) bool {
+		return before(sorted[i], sorted[j], order)
+	})
+
+	start := 0
+	if page.After != nil {
+		start = sort.Search(len(sorted), func(i int) bool {
+			createdAt, id := sorted[i].PageKey()
+			return less(page.After.CreatedAt, page.After.ID, createdAt, id, order)
+		})
+	}
+	end := min(start+page.Limit+1, len(sorted))
+	return NewList(sorted[start:end], page)
+}
+
+// before reports whether a is listed before b
+func before(a, b Keyed, order Order) bool {
+	aCreatedAt, aID := a.PageKey()
+	bCreatedAt, bID := b.PageKey()
+	return less(aCreatedAt, aID, bCreatedAt, bID, order)
+}
+
+func less(aCreatedAt time.Time, aID string, bCreatedAt time.Time, bID string, order Order) bool {
+	if order == NewestFirst {
+		aCreatedAt, aID, bCreatedAt, bID = bCreatedAt, bID, aCreatedAt, aID
+	}
+	if !aCreatedAt.Equal(bCreatedAt) {
+		return aCreatedAt.Before(bCreatedAt)
+	}
+	return aID < bID
+}
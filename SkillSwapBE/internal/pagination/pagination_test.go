@@ -0,0 +1,129 @@
+package pagination
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type row struct {
+	id        string
+	createdAt time.Time
+}
+
+func (r row) PageKey() (time.Time, string) { return r.createdAt, r.id }
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 123456000, time.UTC), ID: "user-7"}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor returned error: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Errorf("Expected %+v, got %+v", cursor, decoded)
+	}
+
+	for _, invalid := range []string{"not base64!", "bm90IGpzb24", Cursor{ID: "user-7"}.Encode()} {
+		if _, err := DecodeCursor(invalid); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantLimit int
+		wantErr   error
+	}{
+		{"", DefaultLimit, nil},
+		{"?limit=5", 5, nil},
+		{"?limit=5000", MaxLimit, nil},
+		{"?limit=0", 0, ErrInvalidLimit},
+		{"?limit=ten", 0, ErrInvalidLimit},
+		{"?cursor=garbage", 0, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		page, err := FromRequest(httptest.NewRequest("GET", "/users"+tt.query, nil))
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%q: expected error %v, got %v", tt.query, tt.wantErr, err)
+			continue
+		}
+		if err == nil && page.Limit != tt.wantLimit {
+			t.Errorf("%q: expected limit %d, got %d", tt.query, tt.wantLimit, page.Limit)
+		}
+	}
+}
+
+func TestSlicePagesThroughEveryRow(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rows []row
+	for i := 0; i < 7; i++ {
+		// Two rows share each timestamp so the ID has to break ties
+		rows = append(rows, row{id: strconv.Itoa(i), createdAt: start.Add(time.Duration(i/2) * time.Hour)})
+	}
+
+	for _, order := range []Order{NewestFirst, OldestFirst} {
+		var seen []string
+		page := Page{Limit: 3}
+		for pages := 0; ; pages++ {
+			if pages > len(rows) {
+				t.Fatalf("Order %d: paging never ended", order)
+			}
+			list := Slice(rows, page, order)
+			for _, r := range list.Data {
+				seen = append(seen, r.id)
+			}
+			if list.NextCursor == nil {
+				break
+			}
+			page.After, _ = DecodeCursor(*list.NextCursor)
+		}
+
+		want := "0123456"
+		if order == NewestFirst {
+			want = "6543210"
+		}
+		got := ""
+		for _, id := range seen {
+			got += id
+		}
+		if got != want {
+			t.Errorf("Order %d: expected rows %s, got %s", order, want, got)
+		}
+	}
+}
+
+func TestNewListMarksLastPage(t *testing.T) {
+	list := NewList([]row(nil), First())
+	if list.Data == nil || list.NextCursor != nil {
+		t.Errorf("Expected an empty last page, got %+v", list)
+	}
+
+	now := time.Now()
+	list = NewList([]row{{"a", now}, {"b", now}}, Page{Limit: 2})
+	if len(list.Data) != 2 || list.NextCursor != nil {
+		t.Errorf("Expected a full last page without a cursor, got %+v", list)
+	}
+}
+
+func TestNewSortedListCarriesSortValue(t *testing.T) {
+	now := time.Now().UTC()
+	rows := []row{{"a", now}, {"b", now}, {"c", now}}
+	list := NewSortedList(rows, Page{Limit: 2}, "rating", func(r row) float64 { return 4.5 })
+
+	if list.NextCursor == nil {
+		t.Fatal("Expected a next cursor")
+	}
+	cursor, err := DecodeCursor(*list.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor returned error: %v", err)
+	}
+	if cursor.Sort != "rating" || cursor.Value != 4.5 || cursor.ID != "b" {
+		t.Errorf("Expected a rating cursor at b, got %+v", cursor)
+	}
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often the memory store drops buckets whose window has ended
+const pruneInterval = time.Minute
+
+type memoryBucket struct {
+	start time.Time
+	count int
+	reset time.Time
+}
+
+// MemoryStore keeps counts in this process. Each instance enforces its own
+// limits, so use PostgresStore when running more than one.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	start := windowStart(limit, now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPrune) >= pruneInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.reset) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastPrune = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok || !bucket.start.Equal(start) {
+		bucket = &memoryBucket{start: start, reset: start.Add(limit.Window)}
+		s.buckets[key] = bucket
+	}
+	bucket.count++
+
+	return result(limit, bucket.count, start), nil
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"skillswap/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresStore keeps counts in the rate_limit_buckets table so that every
+// API instance enforces the same limits
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// takeSQL counts a request with a single upsert, starting the count over
+// when the stored window has ended
+const takeSQL = `
+INSERT INTO rate_limit_buckets (key, window_start, count, expires_at)
+VALUES (?, ?, 1, ?)
+ON CONFLICT (key) DO UPDATE SET
+	count = CASE WHEN rate_limit_buckets.window_start = EXCLUDED.window_start
+		THEN rate_limit_buckets.count + 1 ELSE 1 END,
+	window_start = EXCLUDED.window_start,
+	expires_at = EXCLUDED.expires_at
+RETURNING count`
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	start := windowStart(limit, now).UTC()
+
+	var count int
+	if err := s.db.WithContext(ctx).Raw(takeSQL, key, start, start.Add(limit.Window)).Scan(&count).Error; err != nil {
+		return Result{}, err
+	}
+	return result(limit, count, start), nil
+}
+
+// Prune deletes buckets whose window ended before now
+func (s *PostgresStore) Prune(ctx context.Context, now time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
+
+// PruneEvery runs Prune at every interval until ctx is cancelled. It blocks,
+// so run it in its own goroutine.
+func (s *PostgresStore) PruneEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := s.Prune(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			slog.Error("Rate limit bucket prune failed", "error", err)
+		}
+	}
+}
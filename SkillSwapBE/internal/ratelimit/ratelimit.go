@@ -0,0 +1,81 @@
+// Package ratelimit counts requests per client in fixed windows, either in
+// memory for a single instance or in Postgres to share limits across instances.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests requests per Window. A zero Limit is disabled.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// ParseLimit reads a limit written as requests/window, e.g. "60/1m" or
+// "1000/1h". "off" or "0" disables the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Limit{}, nil
+	}
+
+	requests, window, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/window such as 60/1m", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid window in rate limit %q", s)
+	}
+	return Limit{Requests: n, Window: d}, nil
+}
+
+// Result is the state of a client's bucket after counting a request
+type Result struct {
+	Allowed   bool
+	Remaining int       // Requests left in the window
+	Reset     time.Time // When the window ends and the count starts over
+}
+
+// Store counts a request against key within limit and reports whether it is allowed
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// windowStart aligns now to the start of its fixed window
+func windowStart(limit Limit, now time.Time) time.Time {
+	return now.Truncate(limit.Window)
+}
+
+func result(limit Limit, count int, start time.Time) Result {
+	remaining := limit.Requests - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Allowed:   count <= limit.Requests,
+		Remaining: remaining,
+		Reset:     start.Add(limit.Window),
+	}
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"skillswap/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("60/1m")
+	if err != nil {
+		t.Fatalf("ParseLimit returned error: %v", err)
+	}
+	if limit.Requests != 60 || limit.Window != time.Minute {
+		t.Errorf("Expected 60 requests per minute, got %s", limit)
+	}
+
+	if limit, err := ParseLimit("off"); err != nil || limit.Enabled() {
+		t.Errorf("Expected \"off\" to disable the limit, got %s, %v", limit, err)
+	}
+
+	for _, invalid := range []string{"60", "sixty/1m", "60/minute", "60/0s", "-1/1m"} {
+		if _, err := ParseLimit(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+// testStore checks a store counts requests within a window and starts over in the next one
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Window: time.Minute}
+	now := time.Date(2025, 1, 1, 12, 0, 10, 0, time.UTC)
+
+	for i, remaining := range []int{1, 0} {
+		result, err := store.Take(ctx, "public:203.0.113.7", limit, now)
+		if err != nil {
+			t.Fatalf("Take returned error: %v", err)
+		}
+		if !result.Allowed || result.Remaining != remaining {
+			t.Errorf("Request %d: expected allowed with %d remaining, got %+v", i+1, remaining, result)
+		}
+	}
+
+	result, err := store.Take(ctx, "public:203.0.113.7", limit, now)
+	if err != nil {
+		t.Fatalf("Take returned error: %v", err)
+	}
+	if result.Allowed {
+		t.Error("Expected the third request in the window to be refused")
+	}
+	if want := time.Date(2025, 1, 1, 12, 1, 0, 0, time.UTC); !result.Reset.Equal(want) {
+		t.Errorf("Expected reset at %s, got %s", want, result.Reset)
+	}
+
+	// Other clients have their own count
+	if result, _ := store.Take(ctx, "public:198.51.100.1", limit, now); !result.Allowed {
+		t.Error("Expected another client's request to be allowed")
+	}
+
+	// The count starts over in the next window
+	if result, _ := store.Take(ctx, "public:203.0.113.7", limit, now.Add(time.Minute)); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("Expected a fresh window, got %+v", result)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestPostgresStore(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	store := NewPostgresStore(db)
+	testStore(t, store)
+
+	pruned, err := store.Prune(context.Background(), time.Date(2025, 1, 1, 12, 1, 30, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Prune returned error: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("Expected the ended window to be pruned, got %d rows", pruned)
+	}
+}
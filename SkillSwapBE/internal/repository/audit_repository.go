@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"gorm.io/gorm"
+)
+
+// AuditRepository reads the audit log. Entries are written with audit.Record,
+// inside the transaction making the change.
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// ListAuditLogs retrieves a page of audit entries matching the filter, newest first
+func (r *AuditRepository) ListAuditLogs(filter models.AuditLogFilter, page pagination.Page) (pagination.List[models.AuditLog], error) {
+	var entries []models.AuditLog
+	query := r.db.Model(&models.AuditLog{})
+
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	err := query.Scopes(pagination.Scope(page, pagination.NewestFirst)).Find(&entries).Error
+	return pagination.NewList(entries, page), err
+}
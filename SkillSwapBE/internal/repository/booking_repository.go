@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"skillswap/internal/audit"
+	"skillswap/internal/badges"
+	"skillswap/internal/metrics"
+	"skillswap/internal/models"
+	"time"
+	"gorm.io/gorm"
+)
+
+// ErrBookingClosed is returned when completing a booking that was already
+// completed or cancelled
+var ErrBookingClosed = errors.New("booking is already completed or cancelled")
+
+type BookingRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingRepository(db *gorm.DB) *BookingRepository {
+	return &BookingRepository{db: db}
+}
+
+// GetBookingByID retrieves a booking by ID
+func (r *BookingRepository) GetBookingByID(id string) (*models.Booking, error) {
+	var booking models.Booking
+	err := r.db.First(&booking, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// CompleteBooking marks a pending or confirmed booking as taught and awards
+// the teacher any badges it earns them
+func (r *BookingRepository) CompleteBooking(id string) (*models.Booking, error) {
+	var booking models.Booking
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&booking, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if booking.Status != models.BookingPending && booking.Status != models.BookingConfirmed {
+			return ErrBookingClosed
+		}
+
+		before := booking
+		now := time.Now()
+		booking.Status = models.BookingCompleted
+		booking.CompletedAt = &now
+		err := tx.Model(&booking).Updates(map[string]interface{}{
+			"status":       booking.Status,
+			"completed_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := audit.Record(tx, "booking.complete", "booking", booking.ID, before, booking); err != nil {
+			return err
+		}
+
+		_, err = badges.Evaluate(tx, booking.TeacherID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.BookingsCompleted.Inc()
+	return &booking, nil
+}
+
+// CancelPendingBookings cancels every pending booking a user is part of, as
+// student or teacher, refunding the full price. It returns the number cancelled.
+func (r *BookingRepository) CancelPendingBookings(userID, reason string) (int64, error) {
+	var bookings []models.Booking
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("status = ?", models.BookingPending).
+			Where("student_id = ? OR teacher_id = ?", userID, userID).
+			Find(&bookings).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, booking := range bookings {
+			before := booking
+			booking.Status = models.BookingCancelled
+			booking.CancelReason = reason
+			booking.RefundAmount = booking.TotalPrice
+			booking.RefundedAt = &now
+
+			err := tx.Model(&booking).Updates(map[string]interface{}{
+				"status":        booking.Status,
+				"cancel_reason": booking.CancelReason,
+				"refund_amount": booking.RefundAmount,
+				"refunded_at":   now,
+			}).Error
+			if err != nil {
+				return err
+			}
+			if err := audit.Record(tx, "booking.refund", "booking", booking.ID, before, booking); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(bookings)), nil
+}
+
+// GetBookingsByUser retrieves every booking a user is part of, as student or teacher
+func (r *BookingRepository) GetBookingsByUser(userID string) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.Where("student_id = ? OR teacher_id = ?", userID, userID).
+		Order("scheduled_at DESC").
+		Find(&bookings).Error
+	return bookings, err
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"skillswap/internal/audit"
+	"skillswap/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCategoryExists is returned when a category's slug is already taken
+	ErrCategoryExists = errors.New("a category with this name already exists")
+	// ErrCategoryInUse is returned when deleting a category that has skills or subcategories
+	ErrCategoryInUse = errors.New("category has skills or subcategories")
+	// ErrCategoryCycle is returned when a category would be moved below itself
+	ErrCategoryCycle = errors.New("category can't be moved below itself")
+	// ErrParentNotFound is returned when a category's parent doesn't exist
+	ErrParentNotFound = errors.New("parent category not found")
+)
+
+// categorySubtree selects the category with the slug given as its only
+// parameter and every category below it
+const categorySubtree = `WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE slug = ?
+		UNION SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+	) SELECT id FROM subtree`
+
+// inCategory keeps rows whose column holds the category matching category's
+// slug, or one of its subcategories
+func inCategory(column, category string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(column+" IN ("+categorySubtree+")", models.CategorySlug(category))
+	}
+}
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// ListCategories returns every category in name order
+func (r *CategoryRepository) ListCategories() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Order("name, id").Find(&categories).Error
+	return categories, err
+}
+
+// CountActiveSkills counts the active listings filed directly under each
+// category, leaving out listings from suspended users as search does
+func (r *CategoryRepository) CountActiveSkills() (map[string]int64, error) {
+	var rows []struct {
+		CategoryID string
+		Count      int64
+	}
+	err := r.db.Model(&models.Skill{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("is_active AND category_id IS NOT NULL").
+		Scopes(notSuspended("skills.user_id")).
+		Group("category_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, nil
+}
+
+// CreateCategory adds a category, deriving its slug from its name
+func (r *CategoryRepository) CreateCategory(category *models.Category) error {
+	category.Name = models.NormalizeCategoryName(category.Name)
+	category.Slug = models.CategorySlug(category.Name)
+	if category.Slug == "" {
+		return models.ErrCategorySlug
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkParent(tx, "", category.ParentID); err != nil {
+			return err
+		}
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "category.create", "category", category.ID, nil, category)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrCategoryExists
+	}
+	return err
+}
+
+// UpdateCategory renames a category and moves it below parentID, or to the
+// top level when parentID is nil. Skills in it take the new name.
+func (r *CategoryRepository) UpdateCategory(id, name string, parentID *string) (*models.Category, error) {
+	name = models.NormalizeCategoryName(name)
+	if models.CategorySlug(name) == "" {
+		return nil, models.ErrCategorySlug
+	}
+
+	var category models.Category
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&category, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := checkParent(tx, id, parentID); err != nil {
+			return err
+		}
+		before := category
+
+		category.Name = name
+		category.Slug = models.CategorySlug(name)
+		category.ParentID = parentID
+		updates := map[string]interface{}{"name": category.Name, "slug": category.Slug, "parent_id": parentID}
+		if err := tx.Model(&category).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Skill{}).Where("category_id = ?", id).Update("category", category.Name).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "category.update", "category", id, before, category)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, ErrCategoryExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// DeleteCategory removes a category with no subcategories and no current
+// listings. Deleted listings that were filed under it are left uncategorised.
+func (r *CategoryRepository) DeleteCategory(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.First(&category, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		var inUse int64
+		err := tx.Raw(`SELECT (SELECT COUNT(*) FROM categories WHERE parent_id = ?)
+			+ (SELECT COUNT(*) FROM skills WHERE category_id = ? AND deleted_at IS NULL)`, id, id).Scan(&inUse).Error
+		if err != nil {
+			return err
+		}
+		if inUse > 0 {
+			return ErrCategoryInUse
+		}
+
+		if err := tx.Unscoped().Model(&models.Skill{}).Where("category_id = ?", id).Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&category).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "category.delete", "category", id, category, nil)
+	})
+}
+
+// checkParent makes sure parentID exists and, when moving category id, isn't
+// id itself or below it
+func checkParent(tx *gorm.DB, id string, parentID *string) error {
+	if parentID == nil {
+		return nil
+	}
+
+	var ancestors []string
+	err := tx.Raw(`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = ?
+			UNION SELECT categories.id, categories.parent_id FROM categories JOIN ancestors ON categories.id = ancestors.parent_id
+		) SELECT id FROM ancestors`, *parentID).Scan(&ancestors).Error
+	if err != nil {
+		return err
+	}
+	if len(ancestors) == 0 {
+		return ErrParentNotFound
+	}
+	for _, ancestor := range ancestors {
+		if id != "" && strings.EqualFold(ancestor, id) {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
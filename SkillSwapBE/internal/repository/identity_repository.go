@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"skillswap/internal/audit"
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"gorm.io/gorm"
+)
+
+// ErrLastIdentity is returned when unlinking would leave a user unable to log in
+var ErrLastIdentity = errors.New("cannot remove the last linked identity")
+
+// ErrIdentityExists is returned when a login is linked by someone else first
+var ErrIdentityExists = errors.New("identity is already linked")
+
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// CreateIdentity links a new provider login to a user
+func (r *IdentityRepository) CreateIdentity(identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(identity).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "identity.link", "user", identity.UserID, nil, identity)
+	})
+}
+
+// GetIdentity retrieves the identity for a provider login
+func (r *IdentityRepository) GetIdentity(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.First(&identity, "provider = ? AND subject = ?", provider, subject).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// UpdateClaimedName records the name the identity provider now gives a login.
+// When it differs from the name seen at the previous login, the user's full
+// name follows; a name edited on SkillSwap is otherwise left alone. Returns
+// whether the full name changed.
+func (r *IdentityRepository) UpdateClaimedName(identity *models.UserIdentity, name string) (bool, error) {
+	renamed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Parallel logins all see the old name; only the first records the new one
+		result := tx.Model(&models.UserIdentity{}).
+			Where("id = ? AND claimed_name IS DISTINCT FROM ?", identity.ID, name).
+			Update("claimed_name", name)
+		if result.Error != nil || result.RowsAffected == 0 || identity.ClaimedName == nil {
+			return result.Error
+		}
+
+		var user models.User
+		if err := tx.First(&user, "id = ?", identity.UserID).Error; err != nil {
+			return err
+		}
+		before := user
+		if err := tx.Model(&user).Update("full_name", name).Error; err != nil {
+			return err
+		}
+		renamed = true
+		return audit.Record(tx, "user.claims", "user", user.ID, before, user)
+	})
+	if err == nil {
+		identity.ClaimedName = &name
+	}
+	return renamed, err
+}
+
+// GetIdentitiesByUser retrieves every login linked to a user
+func (r *IdentityRepository) GetIdentitiesByUser(userID string) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// ListIdentitiesByUser retrieves a page of the logins linked to a user, oldest first
+func (r *IdentityRepository) ListIdentitiesByUser(userID string, page pagination.Page) (pagination.List[models.UserIdentity], error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Scopes(pagination.Scope(page, pagination.OldestFirst)).Find(&identities).Error
+	return pagination.NewList(identities, page), err
+}
+
+// DeleteIdentity unlinks a login, refusing to remove a user's last one
+func (r *IdentityRepository) DeleteIdentity(userID, identityID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count <= 1 {
+			return ErrLastIdentity
+		}
+
+		var identity models.UserIdentity
+		if err := tx.First(&identity, "id = ? AND user_id = ?", identityID, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&identity).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "identity.unlink", "user", userID, identity, nil)
+	})
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"skillswap/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LeaderboardRepository ranks users in the public directory. Queries are too
+// heavy to run per request; LeaderboardService caches their results.
+type LeaderboardRepository struct {
+	db *gorm.DB
+}
+
+func NewLeaderboardRepository(db *gorm.DB) *LeaderboardRepository {
+	return &LeaderboardRepository{db: db}
+}
+
+// scoredUser is one place on a leaderboard before the user is loaded
+type scoredUser struct {
+	ID    string
+	Score float64
+}
+
+// TopByPoints ranks users by the points they earned since the given time, or
+// by their total points when since is zero. Users who earned nothing are left
+// off.
+func (r *LeaderboardRepository) TopByPoints(query models.LeaderboardQuery, since time.Time) ([]models.LeaderboardEntry, error) {
+	db := r.scope(query)
+	if since.IsZero() {
+		db = db.Select("users.id, users.points AS score").Where("users.points > 0")
+	} else {
+		db = db.Select("users.id, SUM(points_events.points) AS score").
+			Joins("JOIN points_events ON points_events.user_id = users.id AND points_events.created_at >= ?", since).
+			Group("users.id").
+			Having("SUM(points_events.points) > 0")
+	}
+
+	var scores []scoredUser
+	if err := db.Order("score DESC").Order("users.id").Limit(query.Size).Scan(&scores).Error; err != nil {
+		return nil, err
+	}
+	return r.entries(scores)
+}
+
+// TopRated ranks teachers, users with an active skill listing, by their
+// average rating. Users with fewer than minReviews reviews are left off.
+func (r *LeaderboardRepository) TopRated(query models.LeaderboardQuery) ([]models.LeaderboardEntry, error) {
+	var scores []scoredUser
+	err := r.scope(query).Scopes(teaches("")).
+		Select("users.id, users.rating AS score").
+		Where("users.review_count >= ?", query.MinReviews).
+		Order("score DESC").Order("users.review_count DESC").Order("users.id").
+		Limit(query.Size).
+		Scan(&scores).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.entries(scores)
+}
+
+// scope applies the query's filters to the public directory
+func (r *LeaderboardRepository) scope(query models.LeaderboardQuery) *gorm.DB {
+	db := r.db.Model(&models.User{}).Where("users.anonymized_at IS NULL").Scopes(notSuspended("users.id"))
+	if query.Category != "" {
+		db = db.Scopes(teaches(query.Category))
+	}
+	if query.Location != "" {
+		db = db.Where("users.location ILIKE ?", "%"+query.Location+"%")
+	}
+	return db
+}
+
+// entries loads the users behind scores, keeping their order
+func (r *LeaderboardRepository) entries(scores []scoredUser) ([]models.LeaderboardEntry, error) {
+	entries := make([]models.LeaderboardEntry, 0, len(scores))
+	if len(scores) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]string, len(scores))
+	for i, score := range scores {
+		ids[i] = score.ID
+	}
+	var users []models.User
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	for _, score := range scores {
+		user, ok := byID[score.ID]
+		if !ok {
+			continue // Deleted since the scores were read
+		}
+		entries = append(entries, models.LeaderboardEntry{Position: len(entries) + 1, User: user.Public(), Score: score.Score})
+	}
+	return entries, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// CreateReport adds a report to the moderation queue
+func (r *ReportRepository) CreateReport(report *models.Report) error {
+	return r.db.Create(report).Error
+}
+
+// GetReportByID retrieves a report by ID
+func (r *ReportRepository) GetReportByID(id string) (*models.Report, error) {
+	var report models.Report
+	err := r.db.First(&report, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetReportForUpdate retrieves a report by ID and locks it until the
+// transaction ends, so two moderators can't resolve it at once
+func (r *ReportRepository) GetReportForUpdate(id string) (*models.Report, error) {
+	var report models.Report
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&report, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetOpenReport retrieves a reporter's open report on a target, if any
+func (r *ReportRepository) GetOpenReport(reporterID string, targetType models.ReportTargetType, targetID string) (*models.Report, error) {
+	var report models.Report
+	err := r.db.Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?",
+		reporterID, targetType, targetID, models.ReportOpen).First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports retrieves a page of the moderation queue, oldest first,
+// optionally filtered by status and target type
+func (r *ReportRepository) ListReports(status models.ReportStatus, targetType models.ReportTargetType, page pagination.Page) (pagination.List[models.Report], error) {
+	var reports []models.Report
+	query := r.db.Model(&models.Report{})
+
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	err := query.Scopes(pagination.Scope(page, pagination.OldestFirst)).Find(&reports).Error
+	return pagination.NewList(reports, page), err
+}
+
+// ResolveReport saves a report's resolution and reports whether it was
+// still open. A report that has been resolved already is left as it is.
+func (r *ReportRepository) ResolveReport(report *models.Report) (bool, error) {
+	result := r.db.Model(report).Where("status = ?", models.ReportOpen).
+		Select("status", "resolution", "resolved_by", "resolved_at").
+		Updates(report)
+	return result.RowsAffected == 1, result.Error
+}
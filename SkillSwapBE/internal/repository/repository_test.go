@@ -0,0 +1,666 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"skillswap/internal/audit"
+	"skillswap/internal/badges"
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"skillswap/internal/testutil"
+
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
+// createUser creates a user with a linked "auth0" identity for subject
+func createUser(t *testing.T, db *gorm.DB, subject, username string) *models.User {
+	t.Helper()
+
+	user := &models.User{Username: username, FullName: username}
+	if err := NewUserRepository(db).CreateUser(user); err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+
+	identity := &models.UserIdentity{UserID: user.ID, Provider: "auth0", Subject: subject}
+	if err := NewIdentityRepository(db).CreateIdentity(identity); err != nil {
+		t.Fatalf("failed to create identity for %s: %v", username, err)
+	}
+	return user
+}
+
+// createCategories adds the top-level categories a test files skills under
+func createCategories(t *testing.T, db *gorm.DB, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := NewCategoryRepository(db).CreateCategory(&models.Category{Name: name}); err != nil {
+			t.Fatalf("failed to create category %s: %v", name, err)
+		}
+	}
+}
+
+func TestUserRepositoryGetByIdentity(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+
+	created := createUser(t, db, "auth0|alice", "alice")
+
+	found, err := repo.GetUserByIdentity("auth0", "auth0|alice")
+	if err != nil {
+		t.Fatalf("GetUserByIdentity returned error: %v", err)
+	}
+	if found.ID != created.ID {
+		t.Errorf("Expected user %s, got %s", created.ID, found.ID)
+	}
+
+	if _, err := repo.GetUserByIdentity("corp", "auth0|alice"); err == nil {
+		t.Error("Expected error for a subject under a different provider, got nil")
+	}
+	if _, err := repo.GetUserByIdentity("auth0", "auth0|nobody"); err == nil {
+		t.Error("Expected error for unknown subject, got nil")
+	}
+}
+
+func TestIdentityRepositoryKeepsLastIdentity(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewIdentityRepository(db)
+
+	user := createUser(t, db, "auth0|gail", "gail")
+	identities, err := repo.GetIdentitiesByUser(user.ID)
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("Expected 1 identity, got %d (%v)", len(identities), err)
+	}
+
+	if err := repo.DeleteIdentity(user.ID, identities[0].ID); !errors.Is(err, ErrLastIdentity) {
+		t.Errorf("Expected ErrLastIdentity, got %v", err)
+	}
+
+	corp := &models.UserIdentity{UserID: user.ID, Provider: "corp", Subject: "gail@corp"}
+	if err := repo.CreateIdentity(corp); err != nil {
+		t.Fatalf("CreateIdentity returned error: %v", err)
+	}
+	if err := repo.DeleteIdentity(user.ID, identities[0].ID); err != nil {
+		t.Errorf("DeleteIdentity returned error: %v", err)
+	}
+}
+
+func TestUserRepositoryUpdateUser(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+
+	user := createUser(t, db, "auth0|bob", "bob")
+
+	err := repo.UpdateUser(user.ID, &models.UpdateUserRequest{Bio: "Teaches chess", Location: "Leeds"})
+	if err != nil {
+		t.Fatalf("UpdateUser returned error: %v", err)
+	}
+
+	profile, err := repo.GetUserProfile(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserProfile returned error: %v", err)
+	}
+	if profile.Bio != "Teaches chess" || profile.Location != "Leeds" {
+		t.Errorf("Expected updated bio and location, got %q and %q", profile.Bio, profile.Location)
+	}
+	if profile.FullName != "bob" {
+		t.Errorf("Expected empty fields to be left alone, full name is %q", profile.FullName)
+	}
+}
+
+func TestUserRepositoryListUsersPages(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+
+	for _, name := range []string{"una", "vic", "wes"} {
+		createUser(t, db, "auth0|"+name, name)
+	}
+
+	var names []string
+	page := pagination.Page{Limit: 2}
+	for {
+		users, err := repo.ListUsers("", "", page)
+		if err != nil {
+			t.Fatalf("ListUsers returned error: %v", err)
+		}
+		for _, user := range users.Data {
+			names = append(names, user.Username)
+		}
+		if users.NextCursor == nil {
+			break
+		}
+		if page.After, err = pagination.DecodeCursor(*users.NextCursor); err != nil {
+			t.Fatalf("DecodeCursor returned error: %v", err)
+		}
+	}
+
+	if len(names) != 3 || names[0] != "wes" || names[2] != "una" {
+		t.Errorf("Expected wes, vic, una newest first, got %v", names)
+	}
+}
+
+func TestUserRepositoryListPublicUsers(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+
+	ada := createUser(t, db, "auth0|ada", "ada")
+	ben := createUser(t, db, "auth0|ben", "ben")
+	cat := createUser(t, db, "auth0|cat", "cat")
+	troll := createUser(t, db, "auth0|troll", "troll")
+	gone := createUser(t, db, "auth0|gone", "gone")
+	db.Model(ada).Updates(map[string]interface{}{"location": "Leeds, UK", "rating": 4.9, "points": 50})
+	db.Model(ben).Updates(map[string]interface{}{"location": "York, UK", "rating": 4.2, "points": 700, "rank": models.Intermediate})
+	db.Model(cat).Updates(map[string]interface{}{"location": "Leeds, UK", "rating": 4.9, "points": 10})
+	db.Model(gone).Update("anonymized_at", time.Now())
+	if err := NewSuspensionRepository(db).CreateSuspension(&models.Suspension{UserID: troll.ID, ModeratorID: ada.ID, Reason: "Spam"}); err != nil {
+		t.Fatalf("CreateSuspension returned error: %v", err)
+	}
+	createCategories(t, db, "Games")
+	if err := db.Create(&models.Skill{Title: "Chess", Category: "Games", UserID: ben.ID, Price: 10, Duration: 60, IsActive: true}).Error; err != nil {
+		t.Fatalf("failed to create skill: %v", err)
+	}
+
+	usernames := func(filter models.UserDirectoryFilter, limit int) []string {
+		t.Helper()
+		var names []string
+		page := pagination.Page{Limit: limit}
+		for {
+			users, err := repo.ListPublicUsers(filter, page)
+			if err != nil {
+				t.Fatalf("ListPublicUsers(%+v) returned error: %v", filter, err)
+			}
+			for _, user := range users.Data {
+				names = append(names, user.Username)
+			}
+			if users.NextCursor == nil {
+				return names
+			}
+			page.After, _ = pagination.DecodeCursor(*users.NextCursor)
+		}
+	}
+
+	tests := []struct {
+		filter models.UserDirectoryFilter
+		want   string
+	}{
+		{models.UserDirectoryFilter{}, "[cat ben ada]"},
+		{models.UserDirectoryFilter{Location: "leeds"}, "[cat ada]"},
+		{models.UserDirectoryFilter{Rank: models.Intermediate}, "[ben]"},
+		{models.UserDirectoryFilter{Category: "Games"}, "[ben]"},
+		{models.UserDirectoryFilter{Sort: models.SortRating}, "[cat ada ben]"},
+		{models.UserDirectoryFilter{Sort: models.SortPoints}, "[ben ada cat]"},
+	}
+	for _, tt := range tests {
+		// A page size of 1 makes every row cross a cursor
+		if got := fmt.Sprint(usernames(tt.filter, 1)); got != tt.want {
+			t.Errorf("ListPublicUsers(%+v) = %s, expected %s", tt.filter, got, tt.want)
+		}
+	}
+
+	if _, err := repo.GetPublicUser(troll.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected a suspended user to be hidden, got %v", err)
+	}
+}
+
+func TestUserRepositoryUpdateUserPoints(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewUserRepository(db)
+
+	user := createUser(t, db, "auth0|carol", "carol")
+
+	if err := repo.UpdateUserPoints(user.ID, 600, "booking.completed"); err != nil {
+		t.Fatalf("UpdateUserPoints returned error: %v", err)
+	}
+
+	updated, err := repo.GetUserByIdentity("auth0", "auth0|carol")
+	if err != nil {
+		t.Fatalf("GetUserByIdentity returned error: %v", err)
+	}
+	if updated.Points != 600 {
+		t.Errorf("Expected 600 points, got %d", updated.Points)
+	}
+	if updated.Rank != models.Intermediate {
+		t.Errorf("Expected rank %s, got %s", models.Intermediate, updated.Rank)
+	}
+
+	var history []models.PointsEvent
+	db.Where("user_id = ?", user.ID).Find(&history)
+	if len(history) != 1 || history[0].Points != 600 || history[0].Reason != "booking.completed" {
+		t.Errorf("Expected the award in the points history, got %+v", history)
+	}
+}
+
+func TestLeaderboardRepositoryRanksByWindowAndRating(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	userRepo := NewUserRepository(db)
+	repo := NewLeaderboardRepository(db)
+
+	ada := createUser(t, db, "auth0|ada", "ada")
+	ben := createUser(t, db, "auth0|ben", "ben")
+	cat := createUser(t, db, "auth0|cat", "cat")
+	userRepo.UpdateUserPoints(ada.ID, 500, "test")
+	userRepo.UpdateUserPoints(ben.ID, 100, "test")
+	userRepo.UpdateUserPoints(ben.ID, 50, "test")
+	userRepo.UpdateUserPoints(cat.ID, 80, "test")
+	// Ada earned most of her points long ago
+	db.Exec("UPDATE points_events SET created_at = ? WHERE user_id = ?", time.Now().AddDate(0, -2, 0), ada.ID)
+	userRepo.UpdateUserPoints(ada.ID, 10, "test")
+
+	db.Model(ben).Updates(map[string]interface{}{"rating": 4.8, "review_count": 5, "location": "Leeds, UK"})
+	db.Model(cat).Updates(map[string]interface{}{"rating": 5.0, "review_count": 1})
+	db.Model(ada).Updates(map[string]interface{}{"rating": 4.5, "review_count": 9})
+	createCategories(t, db, "Games")
+	for _, user := range []*models.User{ada, ben, cat} {
+		if err := db.Create(&models.Skill{Title: "Chess", Category: "Games", UserID: user.ID, Price: 10, Duration: 60, IsActive: true}).Error; err != nil {
+			t.Fatalf("failed to create skill: %v", err)
+		}
+	}
+
+	usernames := func(entries []models.LeaderboardEntry, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("leaderboard query returned error: %v", err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, fmt.Sprintf("%d:%s=%g", entry.Position, entry.User.Username, entry.Score))
+		}
+		return fmt.Sprint(names)
+	}
+
+	query := models.LeaderboardQuery{Board: models.BoardPoints, Size: 10}
+	if got := usernames(repo.TopByPoints(query, time.Time{})); got != "[1:ada=510 2:ben=150 3:cat=80]" {
+		t.Errorf("All-time points leaderboard = %s", got)
+	}
+	if got := usernames(repo.TopByPoints(query, models.WindowMonthly.Since(time.Now()))); got != "[1:ben=150 2:cat=80 3:ada=10]" {
+		t.Errorf("Monthly points leaderboard = %s", got)
+	}
+	query.Location = "leeds"
+	if got := usernames(repo.TopByPoints(query, time.Time{})); got != "[1:ben=150]" {
+		t.Errorf("Leeds points leaderboard = %s", got)
+	}
+
+	query = models.LeaderboardQuery{Board: models.BoardRating, MinReviews: 3, Category: "Games", Size: 10}
+	if got := usernames(repo.TopRated(query)); got != "[1:ben=4.8 2:ada=4.5]" {
+		t.Errorf("Rating leaderboard = %s", got)
+	}
+}
+
+func TestReviewRepositoryUpdatesUserRating(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	reviewRepo := NewReviewRepository(db)
+	userRepo := NewUserRepository(db)
+
+	teacher := createUser(t, db, "auth0|teacher", "teacher")
+	student := createUser(t, db, "auth0|student", "student")
+	other := createUser(t, db, "auth0|other", "other")
+
+	first := &models.Review{ReviewerID: student.ID, RevieweeID: teacher.ID, Rating: 5, IsPublic: true}
+	if err := reviewRepo.CreateReview(first); err != nil {
+		t.Fatalf("CreateReview returned error: %v", err)
+	}
+	second := &models.Review{ReviewerID: other.ID, RevieweeID: teacher.ID, Rating: 2, IsPublic: true}
+	if err := reviewRepo.CreateReview(second); err != nil {
+		t.Fatalf("CreateReview returned error: %v", err)
+	}
+
+	updated, err := userRepo.GetUserByIdentity("auth0", "auth0|teacher")
+	if err != nil {
+		t.Fatalf("GetUserByIdentity returned error: %v", err)
+	}
+	if updated.Rating != 3.5 || updated.ReviewCount != 2 {
+		t.Errorf("Expected rating 3.5 from 2 reviews, got %v from %d", updated.Rating, updated.ReviewCount)
+	}
+
+	summary, err := reviewRepo.GetReviewSummary(teacher.ID)
+	if err != nil {
+		t.Fatalf("GetReviewSummary returned error: %v", err)
+	}
+	if summary.TotalReviews != 2 || summary.RatingBreakdown[5] != 1 || summary.RatingBreakdown[2] != 1 {
+		t.Errorf("Unexpected review summary: %+v", summary)
+	}
+
+	// Deleting every review should reset the rating rather than fail on a NULL average
+	for _, review := range []*models.Review{first, second} {
+		if err := reviewRepo.DeleteReview(review.ID); err != nil {
+			t.Fatalf("DeleteReview returned error: %v", err)
+		}
+	}
+
+	updated, err = userRepo.GetUserByIdentity("auth0", "auth0|teacher")
+	if err != nil {
+		t.Fatalf("GetUserByIdentity returned error: %v", err)
+	}
+	if updated.Rating != 0 || updated.ReviewCount != 0 {
+		t.Errorf("Expected rating reset after deletes, got %v from %d", updated.Rating, updated.ReviewCount)
+	}
+}
+
+func TestSuspensionRepositoryActiveSuspension(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewSuspensionRepository(db)
+
+	user := createUser(t, db, "auth0|troll", "troll")
+	mod := createUser(t, db, "auth0|mod", "mod")
+
+	past := time.Now().Add(-time.Hour)
+	if err := repo.CreateSuspension(&models.Suspension{UserID: user.ID, ModeratorID: mod.ID, Reason: "Old", ExpiresAt: &past}); err != nil {
+		t.Fatalf("CreateSuspension returned error: %v", err)
+	}
+	if _, err := repo.GetActiveSuspension(user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected an expired suspension to be inactive, got %v", err)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := repo.CreateSuspension(&models.Suspension{UserID: user.ID, ModeratorID: mod.ID, Reason: "Spam", ExpiresAt: &future}); err != nil {
+		t.Fatalf("CreateSuspension returned error: %v", err)
+	}
+	active, err := repo.GetActiveSuspension(user.ID)
+	if err != nil || active.Reason != "Spam" {
+		t.Fatalf("Expected the Spam suspension to be active, got %+v, %v", active, err)
+	}
+
+	if err := repo.LiftSuspensions(user.ID, mod.ID); err != nil {
+		t.Fatalf("LiftSuspensions returned error: %v", err)
+	}
+	if _, err := repo.GetActiveSuspension(user.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected a lifted suspension to be inactive, got %v", err)
+	}
+	if err := repo.LiftSuspensions(user.ID, mod.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound with nothing to lift, got %v", err)
+	}
+}
+
+func TestSkillRepositorySearchHidesSuspendedUsers(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewSkillRepository(db)
+
+	teacher := createUser(t, db, "auth0|teacher", "teacher")
+	troll := createUser(t, db, "auth0|troll", "troll")
+	createCategories(t, db, "Music")
+	for _, skill := range []models.Skill{
+		{Title: "Guitar", Category: "Music", UserID: teacher.ID, Price: 10, Duration: 60},
+		{Title: "Bass guitar", Category: "Music", UserID: troll.ID, Price: 10, Duration: 60},
+	} {
+		if err := db.Create(&skill).Error; err != nil {
+			t.Fatalf("failed to create skill: %v", err)
+		}
+	}
+
+	if err := NewSuspensionRepository(db).CreateSuspension(&models.Suspension{UserID: troll.ID, ModeratorID: teacher.ID, Reason: "Spam"}); err != nil {
+		t.Fatalf("CreateSuspension returned error: %v", err)
+	}
+
+	skills, err := repo.SearchSkills(models.SkillSearchParams{Query: "guitar"}, pagination.First())
+	if err != nil {
+		t.Fatalf("SearchSkills returned error: %v", err)
+	}
+	if len(skills.Data) != 1 || skills.Data[0].UserID != teacher.ID {
+		t.Errorf("Expected only the teacher's skill, got %+v", skills.Data)
+	}
+}
+
+func TestCategoryTreeAndTags(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	categories := NewCategoryRepository(db)
+	teacher := createUser(t, db, "auth0|teacher", "teacher")
+
+	music := &models.Category{Name: " Music "}
+	if err := categories.CreateCategory(music); err != nil {
+		t.Fatalf("CreateCategory returned error: %v", err)
+	}
+	guitar := &models.Category{Name: "Guitar", ParentID: &music.ID}
+	if err := categories.CreateCategory(guitar); err != nil {
+		t.Fatalf("CreateCategory returned error: %v", err)
+	}
+	if err := categories.CreateCategory(&models.Category{Name: "music"}); !errors.Is(err, ErrCategoryExists) {
+		t.Errorf("Expected ErrCategoryExists for a second Music, got %v", err)
+	}
+
+	// Free text is filed under the category it matches
+	skill := models.Skill{Title: "Jazz guitar", Category: "guitar ", UserID: teacher.ID, Price: 10, Duration: 60, IsActive: true}
+	if err := db.Create(&skill).Error; err != nil {
+		t.Fatalf("failed to create skill: %v", err)
+	}
+	if skill.CategoryID == nil || *skill.CategoryID != guitar.ID || skill.Category != "Guitar" {
+		t.Errorf("Expected the skill filed under Guitar, got %q (%v)", skill.Category, skill.CategoryID)
+	}
+	unknown := models.Skill{Title: "Juggling", Category: "Circus", UserID: teacher.ID, Price: 10, Duration: 60}
+	if err := db.Create(&unknown).Error; !errors.Is(err, models.ErrUnknownCategory) {
+		t.Errorf("Expected ErrUnknownCategory for a category nobody added, got %v", err)
+	}
+
+	tags := NewTagRepository(db)
+	if err := tags.SetSkillTags(&skill, []string{"Jazz", " #jazz", "Blues", ""}); err != nil {
+		t.Fatalf("SetSkillTags returned error: %v", err)
+	}
+
+	// Searching a category includes its subcategories
+	found, err := NewSkillRepository(db).SearchSkills(models.SkillSearchParams{Category: "music", Tag: "JAZZ"}, pagination.First())
+	if err != nil {
+		t.Fatalf("SearchSkills returned error: %v", err)
+	}
+	if len(found.Data) != 1 || len(found.Data[0].Tags) != 2 || found.Data[0].Tags[0].Name != "blues" {
+		t.Errorf("Expected the skill with tags blues and jazz, got %+v", found.Data)
+	}
+
+	suggestions, err := tags.SuggestTags("J", 10)
+	if err != nil {
+		t.Fatalf("SuggestTags returned error: %v", err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Name != "jazz" || suggestions[0].SkillCount != 1 {
+		t.Errorf("Expected jazz on one skill, got %+v", suggestions)
+	}
+
+	counts, err := categories.CountActiveSkills()
+	if err != nil {
+		t.Fatalf("CountActiveSkills returned error: %v", err)
+	}
+	if counts[guitar.ID] != 1 || counts[music.ID] != 0 {
+		t.Errorf("Expected one skill directly under Guitar, got %v", counts)
+	}
+
+	if _, err := categories.UpdateCategory(music.ID, "Music", &guitar.ID); !errors.Is(err, ErrCategoryCycle) {
+		t.Errorf("Expected ErrCategoryCycle moving Music below Guitar, got %v", err)
+	}
+	if err := categories.DeleteCategory(music.ID); !errors.Is(err, ErrCategoryInUse) {
+		t.Errorf("Expected ErrCategoryInUse deleting Music, got %v", err)
+	}
+
+	// Renaming a category renames its skills' category too
+	if _, err := categories.UpdateCategory(guitar.ID, "Guitars", &music.ID); err != nil {
+		t.Fatalf("UpdateCategory returned error: %v", err)
+	}
+	var renamed models.Skill
+	db.First(&renamed, "id = ?", skill.ID)
+	if renamed.Category != "Guitars" {
+		t.Errorf("Expected the skill's category renamed, got %q", renamed.Category)
+	}
+}
+
+func TestBookingRepositoryCancelPendingBookings(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewBookingRepository(db)
+
+	teacher := createUser(t, db, "auth0|teacher", "teacher")
+	student := createUser(t, db, "auth0|student", "student")
+	createCategories(t, db, "Music")
+	skill := models.Skill{Title: "Guitar", Category: "Music", UserID: teacher.ID, Price: 40, Duration: 60}
+	if err := db.Create(&skill).Error; err != nil {
+		t.Fatalf("failed to create skill: %v", err)
+	}
+
+	pending := models.Booking{SkillID: skill.ID, StudentID: student.ID, TeacherID: teacher.ID, ScheduledAt: time.Now(), TotalPrice: 40}
+	completed := models.Booking{SkillID: skill.ID, StudentID: student.ID, TeacherID: teacher.ID, ScheduledAt: time.Now(), TotalPrice: 40, Status: models.BookingCompleted}
+	for _, booking := range []*models.Booking{&pending, &completed} {
+		if err := db.Create(booking).Error; err != nil {
+			t.Fatalf("failed to create booking: %v", err)
+		}
+	}
+
+	cancelled, err := repo.CancelPendingBookings(teacher.ID, "Account suspended")
+	if err != nil {
+		t.Fatalf("CancelPendingBookings returned error: %v", err)
+	}
+	if cancelled != 1 {
+		t.Errorf("Expected 1 booking cancelled, got %d", cancelled)
+	}
+
+	booking, _ := repo.GetBookingByID(pending.ID)
+	if booking.Status != models.BookingCancelled || booking.RefundAmount != 40 || booking.RefundedAt == nil {
+		t.Errorf("Expected a fully refunded cancellation, got %+v", booking)
+	}
+	booking, _ = repo.GetBookingByID(completed.ID)
+	if booking.Status != models.BookingCompleted {
+		t.Errorf("Expected completed booking to be left alone, got status %s", booking.Status)
+	}
+}
+
+func TestCompleteBookingAwardsBadgesOnce(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	repo := NewBookingRepository(db)
+
+	teacher := createUser(t, db, "auth0|tess", "tess")
+	student := createUser(t, db, "auth0|sam", "sam")
+	createCategories(t, db, "Music", "Art", "Cooking")
+	var bookings []models.Booking
+	for _, category := range []string{"Music", "Art", "Cooking"} {
+		skill := models.Skill{Title: category, Category: category, UserID: teacher.ID, Price: 40, Duration: 60}
+		if err := db.Create(&skill).Error; err != nil {
+			t.Fatalf("failed to create skill: %v", err)
+		}
+		booking := models.Booking{SkillID: skill.ID, StudentID: student.ID, TeacherID: teacher.ID, ScheduledAt: time.Now(), TotalPrice: 40}
+		if err := db.Create(&booking).Error; err != nil {
+			t.Fatalf("failed to create booking: %v", err)
+		}
+		bookings = append(bookings, booking)
+	}
+
+	held := func() string {
+		t.Helper()
+		earned, err := badges.Earned(db, teacher.ID)
+		if err != nil {
+			t.Fatalf("Earned returned error: %v", err)
+		}
+		var ids []string
+		for _, badge := range earned {
+			ids = append(ids, badge.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	if _, err := repo.CompleteBooking(bookings[0].ID); err != nil {
+		t.Fatalf("CompleteBooking returned error: %v", err)
+	}
+	if got := held(); got != "[first-lesson]" {
+		t.Errorf("Expected the first lesson badge, got %s", got)
+	}
+	if _, err := repo.CompleteBooking(bookings[0].ID); !errors.Is(err, ErrBookingClosed) {
+		t.Errorf("Expected ErrBookingClosed completing a booking twice, got %v", err)
+	}
+
+	repo.CompleteBooking(bookings[1].ID)
+	repo.CompleteBooking(bookings[2].ID)
+	if got := held(); got != "[first-lesson three-categories]" {
+		t.Errorf("Expected the all-rounder badge after three categories, got %s", got)
+	}
+
+	// Evaluating again doesn't award anything twice
+	awarded, err := badges.Evaluate(db, teacher.ID)
+	if err != nil || len(awarded) != 0 {
+		t.Errorf("Expected nothing new from a repeat evaluation, got %v, %v", awarded, err)
+	}
+	var count int64
+	db.Model(&models.UserBadge{}).Where("user_id = ?", teacher.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected 2 badges held, got %d", count)
+	}
+
+	progress, _ := badges.Progress(db, teacher.ID)
+	for _, badge := range progress {
+		if badge.ID == "ten-lessons" && (badge.Current != 3 || badge.AwardedAt != nil) {
+			t.Errorf("Expected 3 of 10 lessons towards the regular badge, got %+v", badge)
+		}
+	}
+}
+
+func TestAuditLogRecordsChangeInSameTransaction(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	user := createUser(t, db, "auth0|quinn", "quinn")
+
+	ctx := audit.WithActor(context.Background(), audit.Actor{UserID: user.ID, RequestID: "req-42", IP: "203.0.113.9"})
+	if err := NewUserRepository(db.WithContext(ctx)).UpdateUser(user.ID, &models.UpdateUserRequest{Bio: "Plays oboe"}); err != nil {
+		t.Fatalf("UpdateUser returned error: %v", err)
+	}
+
+	entries, err := NewAuditRepository(db).ListAuditLogs(models.AuditLogFilter{EntityType: "user", EntityID: user.ID, Action: "user.update"}, pagination.First())
+	if err != nil {
+		t.Fatalf("ListAuditLogs returned error: %v", err)
+	}
+	if len(entries.Data) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(entries.Data))
+	}
+	entry := entries.Data[0]
+	if entry.ActorID == nil || *entry.ActorID != user.ID || entry.RequestID != "req-42" || entry.IP != "203.0.113.9" {
+		t.Errorf("Expected actor, request ID and IP to be recorded, got %+v", entry)
+	}
+
+	var changes map[string]audit.Change
+	json.Unmarshal(entry.Changes, &changes)
+	if changes["bio"].Before != "" || changes["bio"].After != "Plays oboe" {
+		t.Errorf("Expected the bio change to be recorded, got %s", entry.Changes)
+	}
+	if len(changes) != 1 {
+		t.Errorf("Expected only the bio to change, got %s", entry.Changes)
+	}
+
+	if err := db.Model(&entry).Update("action", "tampered").Error; err == nil {
+		t.Error("Expected updating an audit entry to be rejected")
+	}
+	if err := db.Delete(&entry).Error; err == nil {
+		t.Error("Expected deleting an audit entry to be rejected")
+	}
+}
+
+func TestAuditLogRedactsPersonalFields(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	user := createUser(t, db, "auth0|rae", "rae")
+
+	ctx := audit.WithActor(context.Background(), audit.Actor{UserID: user.ID, IP: "203.0.113.9"})
+	if err := NewUserRepository(db.WithContext(ctx)).UpdateUser(user.ID, &models.UpdateUserRequest{Bio: "Lives on Elm Street"}); err != nil {
+		t.Fatalf("UpdateUser returned error: %v", err)
+	}
+	if err := db.Model(&models.AuditLog{}).Where("entity_id = ?", user.ID).Update("ip", "").Error; err == nil {
+		t.Error("Expected updating an audit entry outside Redact to be rejected")
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error { return audit.Redact(tx, user.ID) }); err != nil {
+		t.Fatalf("Redact returned error: %v", err)
+	}
+	var entry models.AuditLog
+	db.Where("entity_id = ? AND action = ?", user.ID, "user.update").First(&entry)
+	var changes map[string]audit.Change
+	json.Unmarshal(entry.Changes, &changes)
+	if changes["bio"].Before != audit.Redacted || changes["bio"].After != audit.Redacted || entry.IP != "" {
+		t.Errorf("Expected the bio and IP to be redacted, got %s from %q", entry.Changes, entry.IP)
+	}
+
+	// Redaction never reaches beyond the changes and IP
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := audit.Redact(tx, user.ID); err != nil {
+			return err
+		}
+		return tx.Model(&entry).Update("action", "tampered").Error
+	})
+	if err == nil {
+		t.Error("Expected changing an entry's action to be rejected after Redact")
+	}
+}
@@ -1,7 +1,12 @@
 package repository
 
 import (
+	"skillswap/internal/audit"
+	"skillswap/internal/badges"
+	"skillswap/internal/metrics"
 	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"time"
 	"gorm.io/gorm"
 )
 
@@ -15,19 +20,32 @@ func NewReviewRepository(db *gorm.DB) *ReviewRepository {
 
 // CreateReview creates a new review
 func (r *ReviewRepository) CreateReview(review *models.Review) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
 		// Create the review
 		if err := tx.Create(review).Error; err != nil {
 			return err
 		}
+		if err := audit.Record(tx, "review.create", "review", review.ID, nil, review); err != nil {
+			return err
+		}
 		
 		// Update reviewee's rating and count
-		return r.updateUserRating(tx, review.RevieweeID)
+		if err := r.updateUserRating(tx, review.RevieweeID); err != nil {
+			return err
+		}
+		_, err := badges.Evaluate(tx, review.RevieweeID)
+		return err
 	})
+	if err != nil {
+		return err
+	}
+	
+	metrics.ReviewsPosted.Inc()
+	return nil
 }
 
-// GetReviewsByUser retrieves all reviews for a user
-func (r *ReviewRepository) GetReviewsByUser(userID string, isPublic bool) ([]models.Review, error) {
+// GetReviewsByUser retrieves a page of the reviews a user received, newest first
+func (r *ReviewRepository) GetReviewsByUser(userID string, isPublic bool, page pagination.Page) (pagination.List[models.Review], error) {
 	var reviews []models.Review
 	query := r.db.Preload("Reviewer").Where("reviewee_id = ?", userID)
 	
@@ -35,8 +53,8 @@ func (r *ReviewRepository) GetReviewsByUser(userID string, isPublic bool) ([]mod
 		query = query.Where("is_public = ?", true)
 	}
 	
-	err := query.Order("created_at DESC").Find(&reviews).Error
-	return reviews, err
+	err := query.Scopes(pagination.Scope(page, pagination.NewestFirst)).Find(&reviews).Error
+	return pagination.NewList(reviews, page), err
 }
 
 // GetReviewByID retrieves a review by ID
@@ -66,12 +84,23 @@ func (r *ReviewRepository) GetReviewSummary(userID string) (*models.ReviewSummar
 // UpdateReview updates a review
 func (r *ReviewRepository) UpdateReview(review *models.Review) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Review
+		if err := tx.First(&before, "id = ?", review.ID).Error; err != nil {
+			return err
+		}
 		if err := tx.Save(review).Error; err != nil {
 			return err
 		}
+		if err := audit.Record(tx, "review.update", "review", review.ID, before, review); err != nil {
+			return err
+		}
 		
 		// Update reviewee's rating
-		return r.updateUserRating(tx, review.RevieweeID)
+		if err := r.updateUserRating(tx, review.RevieweeID); err != nil {
+			return err
+		}
+		_, err := badges.Evaluate(tx, review.RevieweeID)
+		return err
 	})
 }
 
@@ -86,12 +115,46 @@ func (r *ReviewRepository) DeleteReview(id string) error {
 		if err := tx.Delete(&review).Error; err != nil {
 			return err
 		}
+		if err := audit.Record(tx, "review.delete", "review", review.ID, review, nil); err != nil {
+			return err
+		}
 		
 		// Update reviewee's rating
 		return r.updateUserRating(tx, review.RevieweeID)
 	})
 }
 
+// HideReview takes a review out of public view on a moderator's behalf
+func (r *ReviewRepository) HideReview(id, moderatorID string) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&review, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		before := review
+		now := time.Now()
+		err := tx.Model(&review).Updates(map[string]interface{}{
+			"is_public": false,
+			"hidden_at": now,
+			"hidden_by": moderatorID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := audit.Record(tx, "review.hide", "review", review.ID, before, review); err != nil {
+			return err
+		}
+
+		// Hidden reviews no longer count towards the reviewee's rating
+		return r.updateUserRating(tx, review.RevieweeID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
 // updateUserRating recalculates and updates user's average rating
 func (r *ReviewRepository) updateUserRating(tx *gorm.DB, userID string) error {
 	var avgRating float64
@@ -100,20 +163,28 @@ func (r *ReviewRepository) updateUserRating(tx *gorm.DB, userID string) error {
 	// Calculate average rating
 	err := tx.Model(&models.Review{}).
 		Where("reviewee_id = ? AND is_public = ?", userID, true).
-		Select("AVG(rating) as avg_rating, COUNT(*) as count").
+		Select("COALESCE(AVG(rating), 0) as avg_rating, COUNT(*) as count").
 		Row().Scan(&avgRating, &count)
 	
 	if err != nil {
 		return err
 	}
 	
+	var user models.User
+	if err := tx.Select("id", "rating", "review_count").First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	before := map[string]interface{}{"rating": user.Rating, "review_count": user.ReviewCount}
+	after := map[string]interface{}{"rating": avgRating, "review_count": count}
+	
 	// Update user's rating and review count
-	return tx.Model(&models.User{}).
+	err = tx.Model(&models.User{}).
 		Where("id = ?", userID).
-		Updates(map[string]interface{}{
-			"rating":       avgRating,
-			"review_count": count,
-		}).Error
+		Updates(after).Error
+	if err != nil {
+		return err
+	}
+	return audit.Record(tx, "user.rating", "user", userID, before, after)
 }
 
 // GetReviewsByBooking retrieves reviews for a specific booking
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"skillswap/internal/audit"
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrGalleryFull is returned when a skill already has models.MaxSkillImages images
+	ErrGalleryFull = errors.New("gallery is full")
+	// ErrDuplicateImage is returned when the same image is added to a skill twice
+	ErrDuplicateImage = errors.New("image is already in the gallery")
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidID reports whether id is a UUID, the form every ID takes. Postgres
+// rejects anything else in a uuid column with an error rather than no rows.
+func ValidID(id string) bool {
+	return uuidPattern.MatchString(id)
+}
+
+type SkillRepository struct {
+	db *gorm.DB
+}
+
+func NewSkillRepository(db *gorm.DB) *SkillRepository {
+	return &SkillRepository{db: db}
+}
+
+// GetSkillByID retrieves a skill by ID
+func (r *SkillRepository) GetSkillByID(id string) (*models.Skill, error) {
+	var skill models.Skill
+	err := r.db.First(&skill, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+// GetPublicSkill retrieves an active skill listing with its teacher, gallery
+// and tags, unless the teacher is suspended
+func (r *SkillRepository) GetPublicSkill(id string) (*models.Skill, error) {
+	var skill models.Skill
+	err := r.db.Preload("User").Scopes(withImages, withTags, notSuspended("skills.user_id")).
+		Where("is_active = ?", true).
+		First(&skill, "skills.id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+// SearchSkills finds a page of active skill listings matching the search
+// params, newest first, leaving out listings from suspended users
+func (r *SkillRepository) SearchSkills(params models.SkillSearchParams, page pagination.Page) (pagination.List[models.Skill], error) {
+	var skills []models.Skill
+	query := r.db.Preload("User").Scopes(withImages, withTags, notSuspended("skills.user_id")).Where("is_active = ?", true)
+
+	if params.Category != "" {
+		query = query.Scopes(inCategory("skills.category_id", params.Category))
+	}
+	if params.Tag != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM skill_tags JOIN tags ON tags.id = skill_tags.tag_id WHERE skill_tags.skill_id = skills.id AND tags.name = ?)",
+			models.NormalizeTag(params.Tag))
+	}
+	if params.Location != "" {
+		query = query.Where("location = ?", params.Location)
+	}
+	if params.Level != "" {
+		query = query.Where("level = ?", params.Level)
+	}
+	if params.MinPrice > 0 {
+		query = query.Where("price >= ?", params.MinPrice)
+	}
+	if params.MaxPrice > 0 {
+		query = query.Where("price <= ?", params.MaxPrice)
+	}
+	if params.UserID != "" {
+		query = query.Where("user_id = ?", params.UserID)
+	}
+	if params.Query != "" {
+		pattern := "%" + params.Query + "%"
+		query = query.Where("title ILIKE ? OR description ILIKE ?", pattern, pattern)
+	}
+
+	err := query.Scopes(pagination.Scope(page, pagination.NewestFirst)).Find(&skills).Error
+	return pagination.NewList(skills, page), err
+}
+
+// ListActiveSkillsByUser retrieves a page of a user's active listings, newest first
+func (r *SkillRepository) ListActiveSkillsByUser(userID string, page pagination.Page) (pagination.List[models.Skill], error) {
+	var skills []models.Skill
+	err := r.db.Where("user_id = ? AND is_active = ?", userID, true).
+		Scopes(withImages, withTags, pagination.Scope(page, pagination.NewestFirst)).
+		Find(&skills).Error
+	return pagination.NewList(skills, page), err
+}
+
+// ListPublicSkillsByUser is ListActiveSkillsByUser for other people to see. A
+// suspended user's listings are hidden.
+func (r *SkillRepository) ListPublicSkillsByUser(userID string, page pagination.Page) (pagination.List[models.Skill], error) {
+	var skills []models.Skill
+	err := r.db.Where("user_id = ? AND is_active = ?", userID, true).
+		Scopes(notSuspended("skills.user_id"), withImages, withTags, pagination.Scope(page, pagination.NewestFirst)).
+		Find(&skills).Error
+	return pagination.NewList(skills, page), err
+}
+
+// CountActiveSkillsByUser counts a user's active listings
+func (r *SkillRepository) CountActiveSkillsByUser(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Skill{}).Where("user_id = ? AND is_active = ?", userID, true).Count(&count).Error
+	return count, err
+}
+
+// DeleteSkill removes a skill listing
+func (r *SkillRepository) DeleteSkill(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var skill models.Skill
+		if err := tx.First(&skill, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&skill).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "skill.delete", "skill", id, skill, nil)
+	})
+}
+
+// AddSkillImage adds an image to the end of a skill's gallery, returning
+// ErrGalleryFull once it holds models.MaxSkillImages
+func (r *SkillRepository) AddSkillImage(image *models.SkillImage) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the skill so parallel uploads can't overfill the gallery
+		var skill models.Skill
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&skill, "id = ?", image.SkillID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.SkillImage{}).Where("skill_id = ?", image.SkillID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= models.MaxSkillImages {
+			return ErrGalleryFull
+		}
+
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "skill.image_add", "skill", image.SkillID, nil, image)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateImage
+	}
+	return err
+}
+
+// DeleteSkillImage removes an image from a skill's gallery and returns it
+func (r *SkillRepository) DeleteSkillImage(skillID, imageID string) (*models.SkillImage, error) {
+	var image models.SkillImage
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&image, "id = ? AND skill_id = ?", imageID, skillID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "skill.image_remove", "skill", skillID, image, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// withTags loads each skill's tags in name order
+func withTags(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name")
+	})
+}
+
+// withImages loads each skill's gallery in upload order
+func withImages(db *gorm.DB) *gorm.DB {
+	return db.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	})
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"skillswap/internal/audit"
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"time"
+	"gorm.io/gorm"
+)
+
+// activeSuspensionSQL matches suspensions that haven't been lifted or run out
+const activeSuspensionSQL = "lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())"
+
+type SuspensionRepository struct {
+	db *gorm.DB
+}
+
+func NewSuspensionRepository(db *gorm.DB) *SuspensionRepository {
+	return &SuspensionRepository{db: db}
+}
+
+// CreateSuspension suspends a user
+func (r *SuspensionRepository) CreateSuspension(suspension *models.Suspension) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(suspension).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "user.suspend", "user", suspension.UserID, nil, suspension)
+	})
+}
+
+// GetActiveSuspension retrieves the suspension currently blocking a user, if any.
+// When several overlap, the one that lasts longest wins.
+func (r *SuspensionRepository) GetActiveSuspension(userID string) (*models.Suspension, error) {
+	var suspension models.Suspension
+	err := r.db.Where("user_id = ?", userID).
+		Where(activeSuspensionSQL).
+		Order("expires_at DESC NULLS FIRST").
+		First(&suspension).Error
+	if err != nil {
+		return nil, err
+	}
+	return &suspension, nil
+}
+
+// GetSuspensionsByUser retrieves a user's suspension history, newest first
+func (r *SuspensionRepository) GetSuspensionsByUser(userID string) ([]models.Suspension, error) {
+	var suspensions []models.Suspension
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&suspensions).Error
+	return suspensions, err
+}
+
+// ListSuspensionsByUser retrieves a page of a user's suspension history, newest first
+func (r *SuspensionRepository) ListSuspensionsByUser(userID string, page pagination.Page) (pagination.List[models.Suspension], error) {
+	var suspensions []models.Suspension
+	err := r.db.Where("user_id = ?", userID).Scopes(pagination.Scope(page, pagination.NewestFirst)).Find(&suspensions).Error
+	return pagination.NewList(suspensions, page), err
+}
+
+// LiftSuspensions ends every active suspension for a user
+func (r *SuspensionRepository) LiftSuspensions(userID, moderatorID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var active []models.Suspension
+		if err := tx.Where("user_id = ?", userID).Where(activeSuspensionSQL).Find(&active).Error; err != nil {
+			return err
+		}
+		if len(active) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		now := time.Now()
+		for _, suspension := range active {
+			before := suspension
+			suspension.LiftedAt = &now
+			suspension.LiftedBy = &moderatorID
+			if err := tx.Model(&suspension).Updates(map[string]interface{}{"lifted_at": now, "lifted_by": moderatorID}).Error; err != nil {
+				return err
+			}
+			if err := audit.Record(tx, "user.unsuspend", "user", userID, before, suspension); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// notSuspended excludes rows whose owner, in the given column, is currently suspended
+func notSuspended(userColumn string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("NOT EXISTS (SELECT 1 FROM suspensions WHERE suspensions.user_id = " + userColumn + " AND " + activeSuspensionSQL + ")")
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"strings"
+
+	"skillswap/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// SuggestTags returns up to limit tags starting with prefix that are on an
+// active listing, most used first
+func (r *TagRepository) SuggestTags(prefix string, limit int) ([]models.TagCount, error) {
+	tags := []models.TagCount{}
+	query := r.db.Table("tags").
+		Select("tags.name, COUNT(*) AS skill_count").
+		Joins("JOIN skill_tags ON skill_tags.tag_id = tags.id").
+		Joins("JOIN skills ON skills.id = skill_tags.skill_id AND skills.is_active AND skills.deleted_at IS NULL").
+		Scopes(notSuspended("skills.user_id"))
+	if prefix = models.NormalizeTag(prefix); prefix != "" {
+		pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+		query = query.Where("tags.name LIKE ?", pattern)
+	}
+
+	err := query.Group("tags.name").Order("skill_count DESC, tags.name").Limit(limit).Scan(&tags).Error
+	return tags, err
+}
+
+// SetSkillTags replaces a skill's tags, adding any that don't exist yet.
+// Tags are normalized first.
+func (r *TagRepository) SetSkillTags(skill *models.Skill, names []string) error {
+	names = models.NormalizeTags(names)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tags := make([]models.Tag, len(names))
+		for i, name := range names {
+			tags[i] = models.Tag{Name: name}
+			// Another listing may add the same new tag at the same time
+			if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tags[i]).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&tags[i], "name = ?", name).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(skill).Association("Tags").Replace(tags)
+	})
+}
@@ -1,10 +1,20 @@
 package repository
 
 import (
+	"errors"
+	"skillswap/internal/audit"
 	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"strings"
+	"time"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrUsernameTaken is returned when a username is in use, in any case, or
+// still held for the user who gave it up
+var ErrUsernameTaken = errors.New("is taken")
+
 type UserRepository struct {
 	db *gorm.DB
 }
@@ -28,23 +38,44 @@ func (r *UserRepository) GetUserByID(id string) (*models.User, error) {
 	return &user, nil
 }
 
-// GetUserByAuth0ID retrieves a user by Auth0 ID
-func (r *UserRepository) GetUserByAuth0ID(auth0ID string) (*models.User, error) {
+// GetUserByIdentity retrieves the user linked to an identity provider login
+func (r *UserRepository) GetUserByIdentity(provider, subject string) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, "auth0_id = ?", auth0ID).Error
+	err := r.db.Joins("JOIN user_identities ON user_identities.user_id = users.id").
+		Where("user_identities.provider = ? AND user_identities.subject = ?", provider, subject).
+		First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// CreateUserWithIdentity creates a user together with their first login. It
+// returns ErrIdentityExists, creating nothing, if the login was linked in the
+// meantime, and gorm.ErrDuplicatedKey if the username is taken.
+func (r *UserRepository) CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+
+		// Waits for a parallel first login of the same identity to commit, then skips
+		identity.UserID = user.ID
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(identity)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrIdentityExists
+		}
+		return nil
+	})
+}
+
 // UpdateUser updates a user
 func (r *UserRepository) UpdateUser(userID string, updateReq *models.UpdateUserRequest) error {
 	updates := make(map[string]interface{})
 	
-	if updateReq.Username != "" {
-		updates["username"] = updateReq.Username
-	}
 	if updateReq.FullName != "" {
 		updates["full_name"] = updateReq.FullName
 	}
@@ -54,11 +85,23 @@ func (r *UserRepository) UpdateUser(userID string, updateReq *models.UpdateUserR
 	if updateReq.Bio != "" {
 		updates["bio"] = updateReq.Bio
 	}
-	if updateReq.Avatar != "" {
-		updates["avatar"] = updateReq.Avatar
+	if len(updates) == 0 {
+		return nil
 	}
 	
-	return r.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before, after models.User
+		if err := tx.First(&before, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&after, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "user.update", "user", userID, before, after)
+	})
 }
 
 // SaveUser saves a complete user model
@@ -66,45 +109,242 @@ func (r *UserRepository) SaveUser(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
-// GetUserProfile retrieves complete user profile with skills and reviews
+// GetUserProfile retrieves a user for their profile. Skills and reviews are
+// paged separately with SkillRepository.ListActiveSkillsByUser and
+// ReviewRepository.GetReviewsByUser.
 func (r *UserRepository) GetUserProfile(userID string) (*models.User, error) {
 	var user models.User
-	err := r.db.Preload("Skills", "is_active = ?", true).
-		Preload("ReviewsReceived.Reviewer").
-		Preload("ReviewsReceived", "is_public = ?", true).
-		Find(&user, "id = ?", userID).Error
-	
+	err := r.db.First(&user, "id = ?", userID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// UpdateUserPoints updates user points and rank
-func (r *UserRepository) UpdateUserPoints(userID string, pointsToAdd int) error {
+// UpdateUserPoints updates user points and rank, and records the change in
+// the points history that leaderboards are computed from
+func (r *UserRepository) UpdateUserPoints(userID string, pointsToAdd int, reason string) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		var user models.User
 		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
 			return err
 		}
 		
+		before := user
 		user.Points += pointsToAdd
 		user.UpdateRank()
 		
-		return tx.Save(&user).Error
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.PointsEvent{UserID: userID, Points: pointsToAdd, Reason: reason}).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "user.points", "user", userID, before, user)
 	})
 }
 
-// GetUsersByRank retrieves users by rank
-func (r *UserRepository) GetUsersByRank(rank models.UserRank, limit int) ([]models.User, error) {
+// ListUsers retrieves a page of users for administration, newest first,
+// optionally filtered by role and a username/full name search
+func (r *UserRepository) ListUsers(role models.UserRole, query string, page pagination.Page) (pagination.List[models.User], error) {
 	var users []models.User
-	err := r.db.Where("rank = ?", rank).Limit(limit).Find(&users).Error
-	return users, err
+	db := r.db.Model(&models.User{})
+	
+	if role != "" {
+		db = db.Where("role = ?", role)
+	}
+	if query != "" {
+		pattern := "%" + query + "%"
+		db = db.Where("username ILIKE ? OR full_name ILIKE ?", pattern, pattern)
+	}
+	
+	err := db.Scopes(pagination.Scope(page, pagination.NewestFirst)).Find(&users).Error
+	return pagination.NewList(users, page), err
 }
 
-// GetTopUsers retrieves top users by points
-func (r *UserRepository) GetTopUsers(limit int) ([]models.User, error) {
+// ListPublicUsers retrieves a page of the public directory, newest first unless
+// the filter sorts by rating or points
+func (r *UserRepository) ListPublicUsers(filter models.UserDirectoryFilter, page pagination.Page) (pagination.List[models.User], error) {
 	var users []models.User
-	err := r.db.Order("points DESC").Limit(limit).Find(&users).Error
-	return users, err
+	db := r.db.Model(&models.User{}).Scopes(publicUsers)
+
+	if filter.Rank != "" {
+		db = db.Where("rank = ?", filter.Rank)
+	}
+	if filter.Location != "" {
+		db = db.Where("location ILIKE ?", "%"+filter.Location+"%")
+	}
+	if filter.Category != "" {
+		db = db.Scopes(teaches(filter.Category))
+	}
+
+	switch filter.Sort {
+	case models.SortRating:
+		err := db.Scopes(pagination.SortedScope(page, "rating")).Find(&users).Error
+		return pagination.NewSortedList(users, page, "rating", func(u models.User) float64 { return u.Rating }), err
+	case models.SortPoints:
+		err := db.Scopes(pagination.SortedScope(page, "points")).Find(&users).Error
+		return pagination.NewSortedList(users, page, "points", func(u models.User) float64 { return float64(u.Points) }), err
+	}
+	err := db.Scopes(pagination.Scope(page, pagination.NewestFirst)).Find(&users).Error
+	return pagination.NewList(users, page), err
+}
+
+// GetPublicUser retrieves a user listed in the public directory
+func (r *UserRepository) GetPublicUser(id string) (*models.User, error) {
+	var user models.User
+	err := r.db.Scopes(publicUsers).First(&user, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetPublicUserByUsername retrieves a user in the public directory by their
+// current username, ignoring case
+func (r *UserRepository) GetPublicUserByUsername(username string) (*models.User, error) {
+	var user models.User
+	err := r.db.Scopes(publicUsers).Where("LOWER(username) = LOWER(?)", username).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetPublicUserByOldUsername retrieves the user in the public directory who
+// most recently gave up username, if they did so after since
+func (r *UserRepository) GetPublicUserByOldUsername(username string, since time.Time) (*models.User, error) {
+	var user models.User
+	err := r.db.Scopes(publicUsers).
+		Joins("JOIN username_changes ON username_changes.user_id = users.id").
+		Where("LOWER(username_changes.old_username) = LOWER(?) AND username_changes.changed_at > ?", username, since).
+		Order("username_changes.changed_at DESC").
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UsernameTaken reports whether username, in any case, belongs to someone
+// other than userID, or was given up by someone else after heldSince
+func (r *UserRepository) UsernameTaken(userID, username string, heldSince time.Time) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.User{}).
+		Where("LOWER(username) = LOWER(?) AND id <> ?", username, userID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	err = r.db.Model(&models.UsernameChange{}).
+		Where("LOWER(old_username) = LOWER(?) AND user_id <> ? AND changed_at > ?", username, userID, heldSince).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// TakenUsernames returns, in lower case, every username starting with prefix
+// that is in use or was given up after heldSince
+func (r *UserRepository) TakenUsernames(prefix string, heldSince time.Time) ([]string, error) {
+	pattern := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(prefix)) + "%"
+
+	var taken []string
+	err := r.db.Raw(`SELECT LOWER(username) FROM users WHERE LOWER(username) LIKE ?
+		UNION SELECT LOWER(old_username) FROM username_changes WHERE LOWER(old_username) LIKE ? AND changed_at > ?`,
+		pattern, pattern, heldSince).
+		Scan(&taken).Error
+	return taken, err
+}
+
+// ChangeUsername renames a user and records the old name in their history.
+// It returns ErrUsernameTaken if the name is taken or held for someone else
+// after heldSince. A change of case alone isn't recorded.
+func (r *UserRepository) ChangeUsername(userID, username string, heldSince time.Time) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id", "username").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.Username == username {
+			return nil
+		}
+
+		if !strings.EqualFold(user.Username, username) {
+			taken, err := NewUserRepository(tx).UsernameTaken(userID, username, heldSince)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return ErrUsernameTaken
+			}
+			change := models.UsernameChange{UserID: userID, OldUsername: user.Username, ChangedAt: time.Now()}
+			if err := tx.Create(&change).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&user).Update("username", username).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "user.username", "user", userID,
+			map[string]interface{}{"username": user.Username}, map[string]interface{}{"username": username})
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrUsernameTaken // Claimed by someone else since it was checked
+	}
+	return err
+}
+
+// SetAvatar replaces a user's avatar, or clears it when avatar is empty, and
+// returns the previous avatar URL
+func (r *UserRepository) SetAvatar(userID, avatar, thumbnail string) (string, error) {
+	var previous string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id", "avatar", "avatar_thumbnail").First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		previous = user.Avatar
+
+		updates := map[string]interface{}{"avatar": avatar, "avatar_thumbnail": thumbnail}
+		if err := tx.Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "user.avatar", "user", userID,
+			map[string]interface{}{"avatar": previous, "avatar_thumbnail": user.AvatarThumbnail}, updates)
+	})
+	return previous, err
+}
+
+// publicUsers leaves out users who are suspended or whose account has been anonymised
+func publicUsers(db *gorm.DB) *gorm.DB {
+	return db.Where("anonymized_at IS NULL").Scopes(notSuspended("users.id"))
+}
+
+// teaches keeps users with an active skill listing, in category unless it is empty
+func teaches(category string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		query := "EXISTS (SELECT 1 FROM skills WHERE skills.user_id = users.id AND skills.is_active AND skills.deleted_at IS NULL"
+		if category == "" {
+			return db.Where(query + ")")
+		}
+		return db.Where(query+" AND skills.category_id IN ("+categorySubtree+"))", models.CategorySlug(category))
+	}
+}
+
+// UpdateUserRole changes a user's stored role
+func (r *UserRepository) UpdateUserRole(userID string, role models.UserRole) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+
+		before := user
+		user.Role = role
+		if err := tx.Model(&user).Update("role", role).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, "user.role", "user", userID, before, user)
+	})
 }
\ No newline at end of file
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"skillswap/internal/middleware"
+	"skillswap/internal/openapi"
+	"skillswap/internal/ratelimit"
+	"skillswap/internal/testutil"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+)
+
+// loadSpec parses and validates the served OpenAPI document
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	if err != nil {
+		t.Fatalf("Could not parse the OpenAPI spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("The OpenAPI spec is invalid: %v", err)
+	}
+	return doc
+}
+
+// checkContract wraps handler so every response to a route in the spec is
+// validated against it, failing t when a handler drifts from the contract
+func checkContract(t *testing.T, handler http.Handler) http.Handler {
+	t.Helper()
+
+	specRouter, err := gorillamux.NewRouter(loadSpec(t))
+	if err != nil {
+		t.Fatalf("Could not route the OpenAPI spec: %v", err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil {
+			body, _ = io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+
+		for key, values := range rr.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rr.Code)
+		w.Write(rr.Body.Bytes())
+
+		specReq := r.Clone(context.Background())
+		specReq.Body = io.NopCloser(bytes.NewReader(body))
+		route, pathParams, err := specRouter.FindRoute(specReq)
+		if err != nil {
+			// Unknown paths 404 in the router; TestSpecCoversEveryRoute catches gaps
+			return
+		}
+		if err := validateResponse(route, pathParams, specReq, rr); err != nil {
+			t.Errorf("%s %s returned %d, which breaks the OpenAPI spec: %v\n%s", r.Method, r.URL.Path, rr.Code, err, rr.Body.String())
+		}
+	})
+}
+
+func validateResponse(route *routers.Route, pathParams map[string]string, r *http.Request, rr *httptest.ResponseRecorder) error {
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: rr.Code,
+		Header: rr.Header(),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+	input.SetBodyBytes(rr.Body.Bytes())
+	return openapi3filter.ValidateResponse(context.Background(), input)
+}
+
+func TestSpecCoversEveryRoute(t *testing.T) {
+	doc := loadSpec(t)
+	router := newRouterWithLimits(t, testutil.NewTokenIssuer(t), middleware.DefaultRateLimits)
+
+	// Operational endpoints that aren't part of the API
+	undocumented := map[string]bool{"/metrics": true, openapi.SpecPath: true}
+
+	routed := map[string]bool{}
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || undocumented[path] {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // Subrouter prefixes
+		}
+		for _, method := range methods {
+			routed[method+" "+path] = true
+		}
+		return nil
+	})
+
+	documented := map[string]bool{}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for _, route := range sortedKeys(routed) {
+		if !documented[route] {
+			t.Errorf("%s is routed but missing from openapi.yaml", route)
+		}
+	}
+	for _, route := range sortedKeys(documented) {
+		if !routed[route] {
+			t.Errorf("%s is in openapi.yaml but not routed", route)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestPublicRoutesMatchSpec(t *testing.T) {
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/health", http.StatusOK},
+		{"/health/live", http.StatusOK},
+		{"/api/v1/public/skills?limit=0", http.StatusBadRequest},
+		{"/api/v1/public/skills?cursor=garbage", http.StatusBadRequest},
+		{"/api/v1/public/skills/search?category=Music&limit=0", http.StatusBadRequest},
+		{"/api/v1/public/skills/missing", http.StatusNotFound},
+		{"/api/v1/public/users?rank=Wizard", http.StatusBadRequest},
+		{"/api/v1/public/users?sort=alphabetical", http.StatusBadRequest},
+		{"/api/v1/public/users/missing", http.StatusNotFound},
+		{"/api/v1/public/users/missing/skills", http.StatusNotFound},
+		{"/api/v1/public/media/avatars/missing/missing/full.jpg", http.StatusNotFound},
+		{"/api/v1/public/leaderboards/points?window=yearly", http.StatusBadRequest},
+		{"/api/v1/public/leaderboards/points?limit=0", http.StatusBadRequest},
+		{"/api/v1/public/leaderboards/rating?min_reviews=0", http.StatusBadRequest},
+		{"/api/v1/public/tags?limit=0", http.StatusBadRequest},
+		{"/api/v1/protected/dashboard", http.StatusUnauthorized},
+		{"/api/v1/admin/users", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRateLimitedResponseMatchesSpec(t *testing.T) {
+	limits := middleware.DefaultRateLimits
+	limits.Public = ratelimit.Limit{Requests: 1, Window: limits.Public.Window}
+	router := checkContract(t, newRouterWithLimits(t, testutil.NewTokenIssuer(t), limits))
+
+	// A rejected request still counts against the limit
+	for i, want := range []int{http.StatusBadRequest, http.StatusTooManyRequests} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/skills?limit=0", nil))
+		if rr.Code != want {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, want, rr.Code)
+		}
+	}
+}
+
+func TestSpecIsServed(t *testing.T) {
+	router := newTestRouter(t, testutil.NewTokenIssuer(t))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", openapi.SpecPath, nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Body.String(), "openapi: 3.") {
+		t.Errorf("Expected the OpenAPI document, got %d: %.40s", rr.Code, rr.Body.String())
+	}
+}
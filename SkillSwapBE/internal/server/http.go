@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTP server limits. Slow clients are cut off rather than holding a
+// connection open, while leaving room for large account exports.
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 15 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 120 * time.Second
+	maxHeaderBytes    = 64 << 10
+)
+
+// DrainTimeout is how long in-flight requests get to finish on shutdown,
+// inside the 30 seconds Heroku allows between SIGTERM and SIGKILL
+const DrainTimeout = 25 * time.Second
+
+// NewHTTPServer wraps handler in a server with read, write and idle timeouts
+// and a cap on request header size
+func NewHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		MaxHeaderBytes:    maxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// Run serves on srv.Addr until ctx is cancelled, then stops accepting
+// connections and waits up to drainTimeout for in-flight requests to finish
+func Run(ctx context.Context, srv *http.Server, drainTimeout time.Duration) error {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return serve(ctx, srv, listener, drainTimeout)
+}
+
+func serve(ctx context.Context, srv *http.Server, listener net.Listener, drainTimeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, draining in-flight requests", "timeout", drainTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// Drop whatever is still running rather than hang past the deadline
+		srv.Close()
+		return err
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
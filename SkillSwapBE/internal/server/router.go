@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"skillswap/internal/handlers"
+	"skillswap/internal/metrics"
+	"skillswap/internal/middleware"
+	"skillswap/internal/models"
+	"skillswap/internal/openapi"
+	"skillswap/internal/tracing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+)
+
+// NewRouter registers all API routes. tokenValidator checks bearer tokens on
+// protected routes, which lets tests swap Auth0 for a locally signed key.
+// rateLimiter throttles the public, protected and admin routes.
+func NewRouter(tokenValidator *middleware.TokenValidator, rateLimiter *middleware.RateLimiter) *mux.Router {
+	router := mux.NewRouter()
+
+	// Health check endpoints: liveness (/health is kept for existing probes) and readiness
+	router.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+	router.HandleFunc("/health/live", handlers.HealthCheck).Methods("GET")
+	router.HandleFunc("/health/ready", handlers.ReadinessCheck(tokenValidator)).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.HandleFunc(openapi.SpecPath, openapi.SpecHandler).Methods("GET")
+
+	// API routes
+	api := router.PathPrefix("/api/v1").Subrouter()
+
+	// Public routes (no authentication required)
+	public := api.PathPrefix("/public").Subrouter()
+	public.Use(tracing.Middleware("rate_limit", rateLimiter.PerIP()))
+	public.Use(tracing.Handler)
+	public.HandleFunc("/skills", handlers.GetSkills).Methods("GET")
+	public.HandleFunc("/skills/search", handlers.SearchSkills).Methods("GET") // Before /skills/{id}, which would match "search"
+	public.HandleFunc("/skills/{id}", handlers.GetSkillByID).Methods("GET")
+	public.HandleFunc("/categories", handlers.GetCategories).Methods("GET")
+	public.HandleFunc("/tags", handlers.GetTagSuggestions).Methods("GET")
+	public.HandleFunc("/users", handlers.GetUsers).Methods("GET")
+	public.HandleFunc("/users/by-username/{username}", handlers.GetUserByUsername).Methods("GET")
+	public.HandleFunc("/users/{id}", handlers.GetUserByID).Methods("GET")
+	public.HandleFunc("/users/{id}/skills", handlers.GetUserSkills).Methods("GET")
+	public.HandleFunc("/leaderboards/points", handlers.GetPointsLeaderboard).Methods("GET")
+	public.HandleFunc("/leaderboards/rating", handlers.GetRatingLeaderboard).Methods("GET")
+	public.HandleFunc("/media/{kind}/{owner}/{hash}/{file}", handlers.GetMedia).Methods("GET")
+
+	// Protected routes (authentication required)
+	protected := api.PathPrefix("/protected").Subrouter()
+	protected.Use(tracing.Middleware("validate_token", tokenValidator.Middleware()))
+	protected.Use(tracing.Middleware("rate_limit", rateLimiter.PerUser()))
+	protected.Use(tracing.Middleware("rate_limit_writes", rateLimiter.Writes()))
+	protected.Use(tracing.Middleware("ensure_user", middleware.EnsureUserExists())) // Automatically create users if they don't exist
+	protected.Use(tracing.Handler)
+	protected.HandleFunc("/dashboard", handlers.GetUserDashboard).Methods("GET")
+	protected.HandleFunc("/profile", handlers.GetUserProfile).Methods("GET")
+	protected.HandleFunc("/profile", handlers.UpdateUserProfile).Methods("PUT")
+	protected.HandleFunc("/profile/{id}", handlers.GetUserProfile).Methods("GET")
+	protected.HandleFunc("/profile/{id}/skills", handlers.GetProfileSkills).Methods("GET")
+	protected.HandleFunc("/profile/{id}/reviews", handlers.GetProfileReviews).Methods("GET")
+	protected.HandleFunc("/usernames/availability", handlers.CheckUsernameAvailability).Methods("GET")
+	protected.HandleFunc("/avatar", handlers.UploadAvatar).Methods("PUT")
+	protected.HandleFunc("/avatar", handlers.DeleteAvatar).Methods("DELETE")
+	protected.HandleFunc("/my-skills", handlers.GetMySkills).Methods("GET")
+	protected.HandleFunc("/badges", handlers.GetMyBadges).Methods("GET")
+	protected.HandleFunc("/identities", handlers.GetMyIdentities).Methods("GET")
+	protected.HandleFunc("/identities", handlers.LinkIdentity(tokenValidator)).Methods("POST")
+	protected.HandleFunc("/identities/{id}", handlers.UnlinkIdentity).Methods("DELETE")
+	protected.HandleFunc("/account/export", handlers.ExportAccount).Methods("GET")
+	protected.HandleFunc("/account/deletion", handlers.ScheduleAccountDeletion).Methods("POST")
+	protected.HandleFunc("/account/deletion", handlers.CancelAccountDeletion).Methods("DELETE")
+	protected.HandleFunc("/skills/{id}/images", handlers.UploadSkillImage).Methods("POST")
+	protected.HandleFunc("/skills/{id}/images/{imageId}", handlers.DeleteSkillImage).Methods("DELETE")
+	protected.HandleFunc("/skills/{id}/report", handlers.CreateReport(models.ReportTargetSkill)).Methods("POST")
+	protected.HandleFunc("/users/{id}/report", handlers.CreateReport(models.ReportTargetUser)).Methods("POST")
+	protected.HandleFunc("/reviews/{id}/report", handlers.CreateReport(models.ReportTargetReview)).Methods("POST")
+
+	// Admin routes (moderator or admin role required)
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(tracing.Middleware("validate_token", tokenValidator.Middleware()))
+	admin.Use(tracing.Middleware("rate_limit", rateLimiter.PerUser()))
+	admin.Use(tracing.Middleware("ensure_user", middleware.EnsureUserExists()))
+	admin.Use(tracing.Middleware("require_role", middleware.RequireRole(models.RoleModerator)))
+	admin.Use(tracing.Handler)
+	admin.HandleFunc("/users", handlers.ListUsers).Methods("GET")
+	admin.HandleFunc("/users/{id}/suspend", handlers.SuspendUser).Methods("POST")
+	admin.HandleFunc("/users/{id}/suspend", handlers.LiftSuspension).Methods("DELETE")
+	admin.HandleFunc("/users/{id}/suspensions", handlers.GetUserSuspensions).Methods("GET")
+	admin.Handle("/users/{id}/role", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(handlers.UpdateUserRole))).Methods("PUT")
+	admin.HandleFunc("/skills/{id}", handlers.RemoveSkill).Methods("DELETE")
+	admin.Handle("/categories", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(handlers.CreateCategory))).Methods("POST")
+	admin.Handle("/categories/{id}", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(handlers.UpdateCategory))).Methods("PUT")
+	admin.Handle("/categories/{id}", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(handlers.DeleteCategory))).Methods("DELETE")
+	admin.HandleFunc("/reviews/{id}/hide", handlers.HideReview).Methods("POST")
+	admin.Handle("/audit", middleware.RequireRole(models.RoleAdmin)(http.HandlerFunc(handlers.ListAuditLogs))).Methods("GET")
+	admin.HandleFunc("/reports", handlers.ListReports).Methods("GET")
+	admin.HandleFunc("/reports/{id}", handlers.GetReport).Methods("GET")
+	admin.HandleFunc("/reports/{id}", handlers.ResolveReport).Methods("PUT")
+
+	// Apply middleware
+	router.Use(otelmux.Middleware(tracing.ServiceName))
+	router.Use(tracing.Middleware("request_id", middleware.RequestID))
+	router.Use(tracing.Middleware("logging", middleware.LoggingMiddleware))
+	router.Use(tracing.Middleware("metrics", metrics.Middleware))
+	router.Use(tracing.Middleware("audit_context", middleware.AuditContext))
+
+	return router
+}
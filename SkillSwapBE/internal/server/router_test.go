@@ -0,0 +1,866 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"skillswap/internal/apierror"
+	"skillswap/internal/badges"
+	"skillswap/internal/devauth"
+	"skillswap/internal/handlers"
+	"skillswap/internal/media"
+	"skillswap/internal/middleware"
+	"skillswap/internal/models"
+	"skillswap/internal/pagination"
+	"skillswap/internal/ratelimit"
+	"skillswap/internal/repository"
+	"skillswap/internal/services"
+	"skillswap/internal/testutil"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
+// newTestRouter builds the full router with tokens validated against issuer.
+// Every response is checked against the OpenAPI spec.
+func newTestRouter(t *testing.T, issuer *testutil.TokenIssuer) http.Handler {
+	t.Helper()
+	return checkContract(t, newRouterWithLimits(t, issuer, middleware.DefaultRateLimits))
+}
+
+// newRouterWithLimits builds the full router with the given rate limits
+func newRouterWithLimits(t *testing.T, issuer *testutil.TokenIssuer, limits middleware.RateLimits) *mux.Router {
+	t.Helper()
+
+	// Each test has its own database, so logins and categories cached by an earlier test are stale
+	services.ClearUserCache()
+	t.Cleanup(services.ClearUserCache)
+	services.ClearCategoryCache()
+	t.Cleanup(services.ClearCategoryCache)
+
+	tokenValidator, err := middleware.NewTokenValidator(middleware.IdentityProvider{
+		Name:       "test",
+		Issuer:     issuer.Issuer(),
+		Audience:   issuer.Audience(),
+		RolesClaim: devauth.RolesClaim,
+		KeyFunc:    issuer.KeyFunc,
+	})
+	if err != nil {
+		t.Fatalf("failed to create token validator: %v", err)
+	}
+	return NewRouter(tokenValidator, middleware.NewRateLimiter(ratelimit.NewMemoryStore(), limits))
+}
+
+func TestProtectedRoutesRejectMissingToken(t *testing.T) {
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+
+	req := httptest.NewRequest("GET", "/api/v1/protected/dashboard", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestDashboardCreatesUserOnFirstRequest(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+
+	req := httptest.NewRequest("GET", "/api/v1/protected/dashboard", nil)
+	req.Header.Set("Authorization", "Bearer "+issuer.Token(t, "auth0|erin", "erin@example.com", "Erin Jones"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var dashboard handlers.DashboardData
+	if err := json.Unmarshal(rr.Body.Bytes(), &dashboard); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	if dashboard.User.Email != "erin@example.com" {
+		t.Errorf("Expected email from token claims, got '%s'", dashboard.User.Email)
+	}
+	if dashboard.Stats.Rank != string(models.Novice) {
+		t.Errorf("Expected rank %s, got %s", models.Novice, dashboard.Stats.Rank)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	token := issuer.Token(t, "auth0|finn", "finn@example.com", "Finn")
+
+	req := httptest.NewRequest("PUT", "/api/v1/protected/profile", strings.NewReader(`{"bio": "Pottery teacher", "avatar": "https://tracker.example.net/pixel.png"}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var user models.AccountUser
+	if err := json.Unmarshal(rr.Body.Bytes(), &user); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	if user.Bio != "Pottery teacher" {
+		t.Errorf("Expected updated bio, got '%s'", user.Bio)
+	}
+	if user.Avatar != "" {
+		t.Errorf("Expected the avatar to be set only by uploading, got '%s'", user.Avatar)
+	}
+	if user.Email != "finn@example.com" {
+		t.Errorf("Expected email from token claims, got '%s'", user.Email)
+	}
+}
+
+func TestUpdateProfileRejectsInvalidFields(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+
+	req := httptest.NewRequest("PUT", "/api/v1/protected/profile", strings.NewReader(`{"location": "`+strings.Repeat("x", 101)+`"}`))
+	req.Header.Set("Authorization", "Bearer "+issuer.Token(t, "auth0|gil", "gil@example.com", "Gil"))
+	req.Header.Set("X-Request-ID", "req-invalid")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	var response apierror.Response
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	if response.Error.RequestID != "req-invalid" || len(response.Error.Fields) != 1 || response.Error.Fields[0].Field != "location" {
+		t.Errorf("Expected a field error for location with the request ID, got %s", rr.Body.String())
+	}
+}
+
+// get performs an authenticated GET against the router
+func get(router http.Handler, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAdminRoutesRequireModerator(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+
+	if rr := get(router, "/api/v1/admin/users", issuer.Token(t, "test|user", "", "User")); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a regular user, got %d", http.StatusForbidden, rr.Code)
+	}
+
+	rr := get(router, "/api/v1/admin/users", issuer.Token(t, "test|mod", "", "Mod", "moderator"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d for a moderator, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var users pagination.List[models.User]
+	if err := json.Unmarshal(rr.Body.Bytes(), &users); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	if len(users.Data) != 2 {
+		t.Errorf("Expected 2 users, got %d", len(users.Data))
+	}
+}
+
+func TestAdminRoutesAnswerNotFoundForMalformedIDs(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	adminToken := issuer.Token(t, "test|admin", "", "Admin", "admin")
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{"PUT", "/api/v1/admin/users/not-a-uuid/role", `{"role": "moderator"}`},
+		{"POST", "/api/v1/admin/users/not-a-uuid/suspend", `{"reason": "Spam"}`},
+		{"DELETE", "/api/v1/admin/users/not-a-uuid/suspend", ""},
+		{"GET", "/api/v1/admin/users/not-a-uuid/suspensions", ""},
+		{"DELETE", "/api/v1/admin/skills/not-a-uuid", ""},
+		{"POST", "/api/v1/admin/reviews/not-a-uuid/hide", ""},
+		{"GET", "/api/v1/admin/reports/not-a-uuid", ""},
+		{"PUT", "/api/v1/admin/reports/not-a-uuid", `{"status": "dismissed"}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, http.StatusNotFound, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestSuspendedUserIsBlocked(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	userToken := issuer.Token(t, "test|troll", "", "Troll")
+	modToken := issuer.Token(t, "test|mod", "", "Mod", "moderator")
+
+	rr := get(router, "/api/v1/protected/dashboard", userToken)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var dashboard handlers.DashboardData
+	json.Unmarshal(rr.Body.Bytes(), &dashboard)
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/users/"+dashboard.User.ID+"/suspend", strings.NewReader(`{"reason": "Spam"}`))
+	req.Header.Set("Authorization", "Bearer "+modToken)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	rr = get(router, "/api/v1/protected/dashboard", userToken)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a suspended user, got %d", http.StatusForbidden, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "Spam") {
+		t.Errorf("Expected suspension reason in response, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/v1/admin/users/"+dashboard.User.ID+"/suspend", nil)
+	req.Header.Set("Authorization", "Bearer "+modToken)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+
+	rr = get(router, "/api/v1/protected/dashboard", userToken)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d once the suspension is lifted, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestReportsReachModerationQueue(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	reporterToken := issuer.Token(t, "test|kim", "", "Kim")
+	modToken := issuer.Token(t, "test|mod", "", "Mod", "moderator")
+
+	rr := get(router, "/api/v1/protected/dashboard", issuer.Token(t, "test|lee", "", "Lee"))
+	var dashboard handlers.DashboardData
+	json.Unmarshal(rr.Body.Bytes(), &dashboard)
+
+	req := httptest.NewRequest("POST", "/api/v1/protected/users/"+dashboard.User.ID+"/report", strings.NewReader(`{"reason": "Spam"}`))
+	req.Header.Set("Authorization", "Bearer "+reporterToken)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	rr = get(router, "/api/v1/admin/reports", reporterToken)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a regular user, got %d", http.StatusForbidden, rr.Code)
+	}
+
+	rr = get(router, "/api/v1/admin/reports", modToken)
+	var reports pagination.List[models.Report]
+	json.Unmarshal(rr.Body.Bytes(), &reports)
+	if len(reports.Data) != 1 || reports.Data[0].TargetID != dashboard.User.ID || reports.Data[0].Status != models.ReportOpen {
+		t.Fatalf("Expected one open report in the queue, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest("PUT", "/api/v1/admin/reports/"+reports.Data[0].ID, strings.NewReader(`{"status": "dismissed"}`))
+	req.Header.Set("Authorization", "Bearer "+modToken)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr = get(router, "/api/v1/admin/reports", modToken)
+	json.Unmarshal(rr.Body.Bytes(), &reports)
+	if len(reports.Data) != 0 {
+		t.Errorf("Expected the queue to be empty, got %d reports", len(reports.Data))
+	}
+}
+
+func TestAuditLogQueryIsAdminOnly(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	userToken := issuer.Token(t, "test|rae", "", "Rae")
+
+	req := httptest.NewRequest("PUT", "/api/v1/protected/profile", strings.NewReader(`{"bio": "Sculptor"}`))
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	req.Header.Set("X-Request-ID", "req-audit")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr = get(router, "/api/v1/admin/audit", issuer.Token(t, "test|mod", "", "Mod", "moderator"))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a moderator, got %d", http.StatusForbidden, rr.Code)
+	}
+
+	rr = get(router, "/api/v1/admin/audit?action=user.update", issuer.Token(t, "test|admin", "", "Admin", "admin"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var entries pagination.List[models.AuditLog]
+	json.Unmarshal(rr.Body.Bytes(), &entries)
+	if len(entries.Data) != 1 || entries.Data[0].RequestID != "req-audit" {
+		t.Errorf("Expected the profile update in the audit log, got %s", rr.Body.String())
+	}
+
+	rr = get(router, "/api/v1/admin/audit?since=yesterday", issuer.Token(t, "test|admin", "", "Admin", "admin"))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid time, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+// createTeacher signs a user in through the dashboard and lists a skill for
+// each title, returning the user
+func createTeacher(t *testing.T, router http.Handler, db *gorm.DB, token string, titles ...string) models.AccountUser {
+	t.Helper()
+	var dashboard handlers.DashboardData
+	json.Unmarshal(get(router, "/api/v1/protected/dashboard", token).Body.Bytes(), &dashboard)
+	db.FirstOrCreate(&models.Category{}, models.Category{Name: "Music", Slug: "music"})
+	for _, title := range titles {
+		skill := models.Skill{Title: title, Category: "Music", UserID: dashboard.User.ID, Price: 40, Duration: 60, IsActive: true}
+		if err := db.Create(&skill).Error; err != nil {
+			t.Fatalf("failed to create skill: %v", err)
+		}
+	}
+	return dashboard.User
+}
+
+func TestPublicSkillsPageWithCursor(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	createTeacher(t, router, db, issuer.Token(t, "test|ada", "", "Ada"), "Guitar", "Piano", "Drums")
+
+	seen := map[string]bool{}
+	path := "/api/v1/public/skills?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("Paging never reached the last page")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+
+		var skills pagination.List[models.Skill]
+		if err := json.Unmarshal(rr.Body.Bytes(), &skills); err != nil {
+			t.Fatalf("Could not parse response: %v", err)
+		}
+		if len(skills.Data) > 2 {
+			t.Fatalf("Expected at most 2 skills per page, got %d", len(skills.Data))
+		}
+		for _, skill := range skills.Data {
+			if seen[skill.ID] {
+				t.Errorf("Skill %s was returned twice", skill.ID)
+			}
+			seen[skill.ID] = true
+		}
+		if skills.NextCursor == nil {
+			break
+		}
+		path = "/api/v1/public/skills?limit=2&cursor=" + *skills.NextCursor
+	}
+
+	if len(seen) != 3 {
+		t.Errorf("Expected to page through 3 skills, got %d", len(seen))
+	}
+}
+
+func TestSuspendedUsersSkillsLeavePublicSearch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	createTeacher(t, router, db, issuer.Token(t, "test|ada", "", "Ada"), "Guitar for beginners")
+	troll := createTeacher(t, router, db, issuer.Token(t, "test|troll", "", "Troll"), "Guitar for cheap")
+
+	search := func() []models.Skill {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/skills/search?query=guitar", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var skills pagination.List[models.Skill]
+		json.Unmarshal(rr.Body.Bytes(), &skills)
+		return skills.Data
+	}
+
+	found := search()
+	if len(found) != 2 {
+		t.Fatalf("Expected both guitar listings, got %d", len(found))
+	}
+	var trollSkill string
+	for _, skill := range found {
+		if skill.UserID == troll.ID {
+			trollSkill = skill.ID
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/users/"+troll.ID+"/suspend", strings.NewReader(`{"reason": "Spam"}`))
+	req.Header.Set("Authorization", "Bearer "+issuer.Token(t, "test|mod", "", "Mod", "moderator"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	found = search()
+	if len(found) != 1 || found[0].UserID == troll.ID {
+		t.Errorf("Expected only Ada's listing once Troll is suspended, got %+v", found)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/skills/"+trollSkill, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a suspended user's skill, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestSuspendedUsersSkillsLeaveProtectedProfile(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	trollToken := issuer.Token(t, "test|troll", "", "Troll")
+	troll := createTeacher(t, router, db, trollToken, "Guitar for cheap")
+	viewer := issuer.Token(t, "test|ada", "", "Ada")
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/users/"+troll.ID+"/suspend", strings.NewReader(`{"reason": "Spam"}`))
+	req.Header.Set("Authorization", "Bearer "+issuer.Token(t, "test|mod", "", "Mod", "moderator"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	for _, path := range []string{
+		"/api/v1/protected/profile/" + troll.ID,
+		"/api/v1/protected/profile/" + troll.ID + "/skills",
+	} {
+		if rr := get(router, path, viewer); strings.Contains(rr.Body.String(), "Guitar for cheap") {
+			t.Errorf("Expected %s to hide a suspended user's listing, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestPublicSearchByCategoryAndTag(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	teacher := createTeacher(t, router, db, issuer.Token(t, "test|ada", "", "Ada"))
+
+	categoryRepo := repository.NewCategoryRepository(db)
+	for _, name := range []string{"Guitar", "Cooking"} {
+		if err := categoryRepo.CreateCategory(&models.Category{Name: name}); err != nil {
+			t.Fatalf("failed to create category: %v", err)
+		}
+	}
+
+	skills := map[string]*models.Skill{}
+	for _, category := range []string{"Music", "Guitar", "Cooking"} {
+		skill := &models.Skill{Title: category + " lessons", Category: category, UserID: teacher.ID, Price: 40, Duration: 60, IsActive: true}
+		if err := db.Create(skill).Error; err != nil {
+			t.Fatalf("failed to create skill: %v", err)
+		}
+		skills[category] = skill
+	}
+	if _, err := categoryRepo.UpdateCategory(*skills["Guitar"].CategoryID, "Guitar", skills["Music"].CategoryID); err != nil {
+		t.Fatalf("failed to move Guitar under Music: %v", err)
+	}
+	if err := repository.NewTagRepository(db).SetSkillTags(skills["Guitar"], []string{"jazz"}); err != nil {
+		t.Fatalf("failed to tag skill: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"category=music", []string{"Music lessons", "Guitar lessons"}},
+		{"category=Guitar", []string{"Guitar lessons"}},
+		{"tag=%23Jazz", []string{"Guitar lessons"}},
+		{"category=cooking&tag=jazz", nil},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/skills/search?"+tt.query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", tt.query, http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var found pagination.List[models.Skill]
+		json.Unmarshal(rr.Body.Bytes(), &found)
+		titles := map[string]bool{}
+		for _, skill := range found.Data {
+			titles[skill.Title] = true
+		}
+		if len(titles) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.query, tt.want, titles)
+			continue
+		}
+		for _, title := range tt.want {
+			if !titles[title] {
+				t.Errorf("%s: expected %v, got %v", tt.query, tt.want, titles)
+			}
+		}
+	}
+}
+
+func TestPublicDirectoryHidesPrivateFields(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+
+	rr := get(router, "/api/v1/protected/dashboard", issuer.Token(t, "test|uma", "uma@example.com", "Uma"))
+	var dashboard handlers.DashboardData
+	json.Unmarshal(rr.Body.Bytes(), &dashboard)
+
+	for _, path := range []string{"/api/v1/public/users", "/api/v1/public/users/" + dashboard.User.ID} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", path, http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if !strings.Contains(rr.Body.String(), `"username"`) {
+			t.Errorf("%s: expected the user, got %s", path, rr.Body.String())
+		}
+		for _, private := range []string{`"email"`, `"role"`, "uma@example.com"} {
+			if strings.Contains(rr.Body.String(), private) {
+				t.Errorf("%s: expected %s to be left out, got %s", path, private, rr.Body.String())
+			}
+		}
+	}
+}
+
+func TestProfileHidesPrivateFieldsFromOtherUsers(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	umaToken := issuer.Token(t, "test|uma", "uma@example.com", "Uma")
+	vicToken := issuer.Token(t, "test|vic", "vic@example.com", "Vic")
+
+	var uma, vic handlers.DashboardData
+	json.Unmarshal(get(router, "/api/v1/protected/dashboard", umaToken).Body.Bytes(), &uma)
+	json.Unmarshal(get(router, "/api/v1/protected/dashboard", vicToken).Body.Bytes(), &vic)
+
+	// Vic's pending deletion and review of Uma put private state on both profiles
+	req := httptest.NewRequest("POST", "/api/v1/protected/account/deletion", nil)
+	req.Header.Set("Authorization", "Bearer "+vicToken)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if err := db.Create(&models.Review{ReviewerID: vic.User.ID, RevieweeID: uma.User.ID, Rating: 5, Comment: "Great", IsPublic: true}).Error; err != nil {
+		t.Fatalf("failed to create review: %v", err)
+	}
+
+	for _, path := range []string{"/api/v1/protected/profile/" + vic.User.ID, "/api/v1/protected/profile/" + uma.User.ID} {
+		token := umaToken
+		if strings.HasSuffix(path, uma.User.ID) {
+			token = vicToken
+		}
+		rr := get(router, path, token)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", path, http.StatusOK, rr.Code, rr.Body.String())
+		}
+		for _, private := range []string{`"email"`, `"deletion_scheduled_at"`, `"auth0_id"`, `"reviews_received"`, "@example.com"} {
+			if strings.Contains(rr.Body.String(), private) {
+				t.Errorf("%s: expected %s to be left out, got %s", path, private, rr.Body.String())
+			}
+		}
+	}
+
+	rr := get(router, "/api/v1/protected/profile", umaToken)
+	if !strings.Contains(rr.Body.String(), "uma@example.com") {
+		t.Errorf("Expected your own profile to include your email, got %s", rr.Body.String())
+	}
+}
+
+func TestProfileOnlyShowsDirectoryUsers(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	troll := createTeacher(t, router, db, issuer.Token(t, "test|troll", "", "Troll"))
+	viewer := issuer.Token(t, "test|ada", "", "Ada")
+
+	if rr := get(router, "/api/v1/protected/profile/"+troll.ID, viewer); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/users/"+troll.ID+"/suspend", strings.NewReader(`{"reason": "Spam"}`))
+	req.Header.Set("Authorization", "Bearer "+issuer.Token(t, "test|mod", "", "Mod", "moderator"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+
+	for _, path := range []string{
+		"/api/v1/protected/profile/" + troll.ID,
+		"/api/v1/protected/profile/not-a-uuid",
+		"/api/v1/protected/profile/not-a-uuid/skills",
+		"/api/v1/protected/profile/not-a-uuid/reviews",
+	} {
+		if rr := get(router, path, viewer); rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d: %s", path, http.StatusNotFound, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestNestedUsersArePublic(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	ada := createTeacher(t, router, db, issuer.Token(t, "test|ada", "ada@example.com", "Ada"), "Guitar")
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/skills", nil))
+	var skills pagination.List[models.Skill]
+	json.Unmarshal(rr.Body.Bytes(), &skills)
+	if len(skills.Data) != 1 {
+		t.Fatalf("Expected the one skill, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/skills/"+skills.Data[0].ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var skill map[string]json.RawMessage
+	if err := json.Unmarshal(rr.Body.Bytes(), &skill); err != nil {
+		t.Fatalf("Could not parse response: %v", err)
+	}
+	var teacher map[string]interface{}
+	if err := json.Unmarshal(skill["user"], &teacher); err != nil {
+		t.Fatalf("Could not parse teacher: %v", err)
+	}
+	if teacher["username"] != ada.Username {
+		t.Errorf("Expected the teacher's public profile, got %v", teacher)
+	}
+	for _, private := range []string{"email", "role", "deletion_scheduled_at", "skills", "reviews_received"} {
+		if _, ok := teacher[private]; ok {
+			t.Errorf("Expected %s to be left out of the teacher, got %v", private, teacher)
+		}
+	}
+}
+
+func TestLeaderboardIsCached(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	services.ClearLeaderboardCache()
+	t.Cleanup(services.ClearLeaderboardCache)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+
+	var dashboard handlers.DashboardData
+	json.Unmarshal(get(router, "/api/v1/protected/dashboard", issuer.Token(t, "test|wes", "wes@example.com", "Wes")).Body.Bytes(), &dashboard)
+	userRepo := repository.NewUserRepository(db)
+	userRepo.UpdateUserPoints(dashboard.User.ID, 120, "test")
+
+	leaderboard := func() models.Leaderboard {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/leaderboards/points?window=weekly", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var leaderboard models.Leaderboard
+		json.Unmarshal(rr.Body.Bytes(), &leaderboard)
+		return leaderboard
+	}
+
+	first := leaderboard()
+	if len(first.Entries) != 1 || first.Entries[0].Score != 120 || first.Entries[0].User.Username != dashboard.User.Username {
+		t.Fatalf("Expected Wes in first place with 120 points, got %+v", first.Entries)
+	}
+
+	userRepo.UpdateUserPoints(dashboard.User.ID, 30, "test")
+	if cached := leaderboard(); cached.Entries[0].Score != 120 || !cached.GeneratedAt.Equal(first.GeneratedAt) {
+		t.Errorf("Expected the cached leaderboard, got %+v", cached)
+	}
+
+	services.ClearLeaderboardCache()
+	if fresh := leaderboard(); fresh.Entries[0].Score != 150 {
+		t.Errorf("Expected a recomputed leaderboard with 150 points, got %+v", fresh.Entries)
+	}
+}
+
+func TestBadgesShowOnProfileAndProgress(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	token := issuer.Token(t, "test|tia", "tia@example.com", "Tia")
+
+	var dashboard handlers.DashboardData
+	json.Unmarshal(get(router, "/api/v1/protected/dashboard", token).Body.Bytes(), &dashboard)
+	db.Create(&models.UserBadge{UserID: dashboard.User.ID, BadgeID: "first-lesson", AwardedAt: time.Now()})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/users/"+dashboard.User.ID, nil))
+	var profile models.PublicProfile
+	json.Unmarshal(rr.Body.Bytes(), &profile)
+	if len(profile.Badges) != 1 || profile.Badges[0].Name != "First Lesson" {
+		t.Errorf("Expected the first lesson badge on the public profile, got %s", rr.Body.String())
+	}
+
+	rr = get(router, "/api/v1/protected/badges", token)
+	var progress pagination.List[models.BadgeProgress]
+	json.Unmarshal(rr.Body.Bytes(), &progress)
+	if len(progress.Data) != len(badges.Definitions) {
+		t.Fatalf("Expected progress on every badge, got %s", rr.Body.String())
+	}
+	for _, badge := range progress.Data {
+		if earned := badge.ID == "first-lesson"; earned != (badge.AwardedAt != nil) {
+			t.Errorf("Expected only the first lesson badge to be earned, got %+v", badge)
+		}
+	}
+}
+
+func TestUsernameChangeMovesVanityURL(t *testing.T) {
+	testutil.NewTestDB(t)
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	uma := issuer.Token(t, "test|uma", "uma@example.com", "Uma")
+	vic := issuer.Token(t, "test|vic", "vic@example.com", "Vic")
+	get(router, "/api/v1/protected/dashboard", vic)
+
+	putUsername := func(token, username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/api/v1/protected/profile", strings.NewReader(`{"username": "`+username+`"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := putUsername(uma, "UmaPaints"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/users/by-username/umapaints", nil))
+	var profile models.PublicProfile
+	json.Unmarshal(rr.Body.Bytes(), &profile)
+	if rr.Code != http.StatusOK || profile.Username != "UmaPaints" {
+		t.Errorf("Expected the new username to find Uma ignoring case, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/public/users/by-username/uma", nil))
+	if rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != "/api/v1/public/users/by-username/UmaPaints" {
+		t.Errorf("Expected the old username to redirect, got %d to %q", rr.Code, rr.Header().Get("Location"))
+	}
+
+	for username, status := range map[string]int{"UMA": http.StatusConflict, "umapaints": http.StatusConflict, "admin": http.StatusUnprocessableEntity, "v": http.StatusUnprocessableEntity} {
+		if rr := putUsername(vic, username); rr.Code != status {
+			t.Errorf("Expected status %d renaming to %q, got %d: %s", status, username, rr.Code, rr.Body.String())
+		}
+	}
+
+	var availability models.UsernameAvailability
+	json.Unmarshal(get(router, "/api/v1/protected/usernames/availability?username=uma", vic).Body.Bytes(), &availability)
+	if availability.Available || availability.Reason == "" {
+		t.Errorf("Expected Uma's old username to be unavailable with a reason, got %+v", availability)
+	}
+}
+
+// upload sends data as the multipart "file" field, as browsers post files
+func upload(t *testing.T, router http.Handler, method, path, token string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "upload")
+	if err != nil {
+		t.Fatalf("failed to build upload: %v", err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAvatarUploadIsServed(t *testing.T) {
+	testutil.NewTestDB(t)
+	previous := media.CurrentStorage()
+	media.SetStorage(media.NewLocalStorage(t.TempDir()))
+	t.Cleanup(func() { media.SetStorage(previous) })
+
+	issuer := testutil.NewTokenIssuer(t)
+	router := newTestRouter(t, issuer)
+	token := issuer.Token(t, "auth0|rosa", "rosa@example.com", "Rosa")
+
+	var photo bytes.Buffer
+	png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 800, 600)))
+
+	if rr := upload(t, router, "PUT", "/api/v1/protected/avatar", token, []byte("not an image")); rr.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status %d for a text file, got %d: %s", http.StatusUnsupportedMediaType, rr.Code, rr.Body.String())
+	}
+
+	rr := upload(t, router, "PUT", "/api/v1/protected/avatar", token, photo.Bytes())
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var avatar models.UploadedImage
+	json.Unmarshal(rr.Body.Bytes(), &avatar)
+	if avatar.Width != 512 || avatar.Height != 512 {
+		t.Errorf("Expected a 512x512 avatar, got %dx%d", avatar.Width, avatar.Height)
+	}
+
+	var dashboard handlers.DashboardData
+	json.Unmarshal(get(router, "/api/v1/protected/dashboard", token).Body.Bytes(), &dashboard)
+	if dashboard.User.Avatar != avatar.URL || dashboard.User.AvatarThumbnail != avatar.ThumbnailURL {
+		t.Errorf("Expected the upload to become the avatar, got %q and %q", dashboard.User.Avatar, dashboard.User.AvatarThumbnail)
+	}
+
+	thumb := get(router, strings.TrimPrefix(avatar.ThumbnailURL, "http://example.com"), "")
+	if thumb.Code != http.StatusOK || thumb.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Expected the thumbnail to be served, got %d %s", thumb.Code, thumb.Header().Get("Content-Type"))
+	}
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/api/v1/protected/avatar", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusNoContent, rr.Code, rr.Body.String())
+	}
+	if rr := get(router, strings.TrimPrefix(avatar.ThumbnailURL, "http://example.com"), ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected the removed avatar to be gone, got %d", rr.Code)
+	}
+
+	if rr := upload(t, router, "POST", "/api/v1/protected/skills/not-a-uuid/images", token, photo.Bytes()); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a malformed skill ID, got %d: %s", http.StatusNotFound, rr.Code, rr.Body.String())
+	}
+}
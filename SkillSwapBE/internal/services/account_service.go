@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"skillswap/internal/audit"
+	"skillswap/internal/database"
+	"skillswap/internal/media"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AccountDeletionGracePeriod is how long a user has to change their mind
+// before their account is anonymised
+const AccountDeletionGracePeriod = 30 * 24 * time.Hour
+
+// ErrNoDeletionScheduled is returned when cancelling a deletion that wasn't requested
+var ErrNoDeletionScheduled = errors.New("no account deletion is scheduled")
+
+type AccountService struct{}
+
+func NewAccountService() *AccountService {
+	return &AccountService{}
+}
+
+// ExportUserData gathers everything stored about a user
+func (s *AccountService) ExportUserData(ctx context.Context, userID string) (*models.AccountExport, error) {
+	db := database.DB.WithContext(ctx)
+	export := &models.AccountExport{ExportedAt: time.Now().UTC()}
+
+	if err := db.First(&export.Profile, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	var err error
+	if export.Identities, err = repository.NewIdentityRepository(db).GetIdentitiesByUser(userID); err != nil {
+		return nil, fmt.Errorf("failed to export identities: %v", err)
+	}
+	if err := db.Preload("Tags").Where("user_id = ?", userID).Order("created_at").Find(&export.Skills).Error; err != nil {
+		return nil, fmt.Errorf("failed to export skills: %v", err)
+	}
+	if export.Bookings, err = repository.NewBookingRepository(db).GetBookingsByUser(userID); err != nil {
+		return nil, fmt.Errorf("failed to export bookings: %v", err)
+	}
+	if err := db.Where("reviewer_id = ?", userID).Order("created_at").Find(&export.ReviewsGiven).Error; err != nil {
+		return nil, fmt.Errorf("failed to export reviews: %v", err)
+	}
+	if err := db.Where("reviewee_id = ?", userID).Order("created_at").Find(&export.ReviewsReceived).Error; err != nil {
+		return nil, fmt.Errorf("failed to export reviews: %v", err)
+	}
+	if err := db.Where("reporter_id = ?", userID).Order("created_at").Find(&export.ReportsFiled).Error; err != nil {
+		return nil, fmt.Errorf("failed to export reports: %v", err)
+	}
+	if export.Suspensions, err = repository.NewSuspensionRepository(db).GetSuspensionsByUser(userID); err != nil {
+		return nil, fmt.Errorf("failed to export suspensions: %v", err)
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&export.PointsHistory).Error; err != nil {
+		return nil, fmt.Errorf("failed to export points history: %v", err)
+	}
+	if err := db.Where("user_id = ?", userID).Order("awarded_at").Find(&export.Badges).Error; err != nil {
+		return nil, fmt.Errorf("failed to export badges: %v", err)
+	}
+	if err := db.Where("user_id = ?", userID).Order("changed_at").Find(&export.UsernameHistory).Error; err != nil {
+		return nil, fmt.Errorf("failed to export username history: %v", err)
+	}
+
+	return export, nil
+}
+
+// ScheduleDeletion marks an account for anonymisation once the grace period ends
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID string) (time.Time, error) {
+	scheduledAt := time.Now().Add(AccountDeletionGracePeriod)
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", userID).Update("deletion_scheduled_at", scheduledAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return audit.Record(tx, "account.deletion_scheduled", "user", userID, nil,
+			map[string]interface{}{"deletion_scheduled_at": scheduledAt})
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+	ForgetUser(userID)
+
+	slog.InfoContext(ctx, "Scheduled account deletion", "target_user_id", userID, "deletion_scheduled_at", scheduledAt)
+	return scheduledAt, nil
+}
+
+// CancelDeletion keeps an account that was scheduled for deletion
+func (s *AccountService) CancelDeletion(ctx context.Context, userID string) error {
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND deletion_scheduled_at IS NOT NULL", userID).
+			Update("deletion_scheduled_at", nil)
+		if result.Error != nil {
+			return fmt.Errorf("failed to cancel account deletion: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrNoDeletionScheduled
+		}
+		return audit.Record(tx, "account.deletion_cancelled", "user", userID, nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+	ForgetUser(userID)
+
+	slog.InfoContext(ctx, "Cancelled account deletion", "target_user_id", userID)
+	return nil
+}
+
+// PurgeDueAccounts anonymises every account whose grace period ended before now
+func (s *AccountService) PurgeDueAccounts(now time.Time) (int, error) {
+	var userIDs []string
+	err := database.DB.Model(&models.User{}).
+		Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", now).
+		Pluck("id", &userIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i, userID := range userIDs {
+		if err := s.AnonymizeUser(userID); err != nil {
+			return i, err
+		}
+	}
+	return len(userIDs), nil
+}
+
+// AnonymizeUser removes a user's personal data. The User row is kept, stripped
+// of anything identifying, so bookings stay intact as financial records.
+func (s *AccountService) AnonymizeUser(userID string) error {
+	var mediaKeys []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Uploads are deleted from storage once the rows no longer point at them
+		var user models.User
+		if err := tx.Select("id", "avatar").Where("id = ?", userID).Find(&user).Error; err != nil {
+			return err
+		}
+		if key, ok := media.KeyFromURL(user.Avatar); ok {
+			mediaKeys = append(mediaKeys, key)
+		}
+		userSkills := tx.Unscoped().Model(&models.Skill{}).Select("id").Where("user_id = ?", userID)
+		var imageKeys []string
+		if err := tx.Model(&models.SkillImage{}).Where("skill_id IN (?)", userSkills).Pluck("key", &imageKeys).Error; err != nil {
+			return err
+		}
+		mediaKeys = append(mediaKeys, imageKeys...)
+		if err := tx.Where("skill_id IN (?)", userSkills).Delete(&models.SkillImage{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":              "deleted-" + strings.ReplaceAll(userID, "-", ""),
+			"full_name":             "Deleted user",
+			"location":              "",
+			"avatar":                "",
+			"avatar_thumbnail":      "",
+			"bio":                   "",
+			"deletion_scheduled_at": nil,
+			"anonymized_at":         now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		// Only the action is recorded: a diff would keep the personal data around
+		if err := audit.Record(tx, "account.anonymize", "user", userID, nil, nil); err != nil {
+			return err
+		}
+		// Earlier entries hold it too. Redact before the skills they're found by go.
+		if err := audit.Redact(tx, userID); err != nil {
+			return err
+		}
+
+		// Without identities, signing in again starts a fresh account
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+
+		// Old usernames would still lead to the account, and can be claimed again
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UsernameChange{}).Error; err != nil {
+			return err
+		}
+
+		// Reports keep their outcome, but not what the user wrote or had held back
+		if err := tx.Model(&models.Report{}).Where("reporter_id = ?", userID).Update("details", "").Error; err != nil {
+			return err
+		}
+		err = tx.Model(&models.Report{}).Where("target_type = ? AND target_id = ?", models.ReportTargetUser, userID).
+			Updates(map[string]interface{}{"held_content": "", "held_over": ""}).Error
+		if err != nil {
+			return err
+		}
+
+		// Bookings stay as financial records, without the notes the user left
+		if err := tx.Unscoped().Model(&models.Booking{}).Where("student_id = ?", userID).
+			Updates(map[string]interface{}{"notes": "", "student_notes": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Booking{}).Where("teacher_id = ?", userID).Update("teacher_notes", "").Error; err != nil {
+			return err
+		}
+
+		// Keep ratings so other users' averages don't shift, but drop the words
+		if err := tx.Unscoped().Model(&models.Review{}).Where("reviewer_id = ?", userID).Update("comment", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Review{}).Where("reviewee_id = ?", userID).Update("is_public", false).Error; err != nil {
+			return err
+		}
+
+		// Listings nobody booked go for good; booked ones stay for the bookings' sake
+		if err := tx.Unscoped().Where("user_id = ? AND NOT EXISTS (SELECT 1 FROM bookings WHERE bookings.skill_id = skills.id)", userID).
+			Delete(&models.Skill{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Skill{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"is_active":   false,
+			"description": "",
+			"location":    "",
+			"deleted_at":  now,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to anonymise user %s: %v", userID, err)
+	}
+	ForgetUser(userID)
+	for _, key := range mediaKeys {
+		removeMedia(context.Background(), key)
+	}
+
+	slog.Info("Anonymised account", "target_user_id", userID)
+	return nil
+}
+
+// PurgeEvery runs PurgeDueAccounts now and then at every interval until ctx
+// is cancelled. It blocks, so run it in its own goroutine.
+func (s *AccountService) PurgeEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := s.PurgeDueAccounts(time.Now()); err != nil {
+			slog.Error("Account purge failed", "purged", purged, "error", err)
+		} else if purged > 0 {
+			slog.Info("Purged accounts past their deletion grace period", "purged", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
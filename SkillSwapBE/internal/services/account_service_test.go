@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"skillswap/internal/audit"
+	"skillswap/internal/models"
+	"skillswap/internal/moderation"
+	"skillswap/internal/repository"
+	"skillswap/internal/testutil"
+
+	"gorm.io/gorm"
+)
+
+func TestAccountDeletionAnonymisesAfterGracePeriod(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	users := NewUserService()
+	service := NewAccountService()
+
+	teacher, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|mia", "mia@example.com", "Mia Brown")
+	student, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|ned", "ned@example.com", "Ned")
+
+	db.Create(&models.Category{Name: "Music", Slug: "music"})
+	booked := models.Skill{Title: "Guitar", Description: "Call me", Category: "Music", UserID: teacher.ID, Price: 30, Duration: 60}
+	unbooked := models.Skill{Title: "Piano", Category: "Music", UserID: teacher.ID, Price: 30, Duration: 60}
+	db.Create(&booked)
+	db.Create(&unbooked)
+	booking := models.Booking{SkillID: booked.ID, StudentID: student.ID, TeacherID: teacher.ID, ScheduledAt: time.Now(), TotalPrice: 30, Status: models.BookingCompleted}
+	db.Create(&booking)
+	review := models.Review{ReviewerID: teacher.ID, RevieweeID: student.ID, Rating: 5, Comment: "Great student, Ned from Leeds"}
+	repository.NewReviewRepository(db).CreateReview(&review)
+
+	scheduledAt, err := service.ScheduleDeletion(context.Background(), teacher.ID)
+	if err != nil {
+		t.Fatalf("ScheduleDeletion returned error: %v", err)
+	}
+
+	if purged, _ := service.PurgeDueAccounts(time.Now()); purged != 0 {
+		t.Fatalf("Expected no accounts purged during the grace period, got %d", purged)
+	}
+	if purged, err := service.PurgeDueAccounts(scheduledAt.Add(time.Minute)); err != nil || purged != 1 {
+		t.Fatalf("Expected 1 account purged after the grace period, got %d, %v", purged, err)
+	}
+
+	anonymised, _ := users.GetUserByID(context.Background(), teacher.ID)
+	if anonymised.FullName != "Deleted user" || anonymised.AnonymizedAt == nil {
+		t.Errorf("Expected user to be anonymised, got %+v", anonymised)
+	}
+	if _, err := users.GetUserByIdentity("auth0", "auth0|mia"); err == nil {
+		t.Error("Expected the user's identities to be removed")
+	}
+
+	var remaining models.Booking
+	if err := db.First(&remaining, "id = ?", booking.ID).Error; err != nil || remaining.TotalPrice != 30 {
+		t.Errorf("Expected the booking to be kept intact, got %+v, %v", remaining, err)
+	}
+	var skillCount int64
+	db.Unscoped().Model(&models.Skill{}).Where("id = ?", unbooked.ID).Count(&skillCount)
+	if skillCount != 0 {
+		t.Error("Expected the unbooked skill to be removed for good")
+	}
+	var reviewed models.Review
+	db.First(&reviewed, "id = ?", review.ID)
+	if reviewed.Comment != "" || reviewed.Rating != 5 {
+		t.Errorf("Expected the review comment to be removed and its rating kept, got %+v", reviewed)
+	}
+}
+
+func TestAnonymisationLeavesNoPersonalData(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	moderation.SetScreener(moderation.NewWordList("casino"))
+	t.Cleanup(func() { moderation.SetScreener(moderation.Chain{}) })
+
+	users := NewUserService()
+	user, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|zq-7f3", "zquartermain@example.com", "Zelda Quartermain")
+	other, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|ned", "ned@example.com", "Ned")
+	firstUsername := user.Username
+
+	// Everything below is written by the user, from their IP
+	ctx := audit.WithActor(context.Background(), audit.Actor{UserID: user.ID, IP: "198.51.100.77"})
+	if err := repository.NewUserRepository(db.WithContext(ctx)).UpdateUser(user.ID, &models.UpdateUserRequest{Bio: "Lives on Elm Street", Location: "Elmsworth"}); err != nil {
+		t.Fatalf("UpdateUser returned error: %v", err)
+	}
+	if err := NewUsernameService().ChangeUsername(ctx, user.ID, "zeldaquarter"); err != nil {
+		t.Fatalf("ChangeUsername returned error: %v", err)
+	}
+	if _, err := users.UpdateProfile(ctx, user.ID, &models.UpdateUserRequest{Bio: "Casino nights on Elm Street"}); err != nil {
+		t.Fatalf("UpdateProfile returned error: %v", err)
+	}
+	if _, err := NewModerationService().ReportContent(ctx, user.ID, models.ReportTargetUser, other.ID, models.CreateReportRequest{Reason: "Spam", Details: "Came by Elm Street"}); err != nil {
+		t.Fatalf("ReportContent returned error: %v", err)
+	}
+	db.Create(&models.Category{Name: "Music", Slug: "music"})
+	skill := models.Skill{Title: "Guitar", Category: "Music", UserID: other.ID, Price: 30, Duration: 60}
+	db.Create(&skill)
+	db.Create(&models.Booking{SkillID: skill.ID, StudentID: user.ID, TeacherID: other.ID, ScheduledAt: time.Now(), TotalPrice: 30, Notes: "Ring twice at Elm Street"})
+
+	if err := NewAccountService().AnonymizeUser(user.ID); err != nil {
+		t.Fatalf("AnonymizeUser returned error: %v", err)
+	}
+
+	var tables []string
+	db.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'").Scan(&tables)
+	personal := []string{"Zelda Quartermain", "Elm Street", "Elmsworth", firstUsername, "zeldaquarter", "auth0|zq-7f3", "198.51.100.77"}
+	for _, table := range tables {
+		for _, value := range personal {
+			var count int64
+			if err := db.Raw(fmt.Sprintf("SELECT COUNT(*) FROM %q t WHERE t::text ILIKE ?", table), "%"+value+"%").Scan(&count).Error; err != nil {
+				t.Fatalf("failed to search %s: %v", table, err)
+			}
+			if count > 0 {
+				t.Errorf("Expected no %q left in %s, found %d rows", value, table, count)
+			}
+		}
+	}
+
+	for _, username := range []string{firstUsername, "zeldaquarter"} {
+		if _, _, err := NewUsernameService().ResolveUsername(context.Background(), username); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("Expected %s to no longer lead anywhere, got %v", username, err)
+		}
+	}
+}
+
+func TestCancelAccountDeletion(t *testing.T) {
+	testutil.NewTestDB(t)
+	user, _ := NewUserService().GetOrCreateUser(context.Background(), "auth0", "auth0|ola", "ola@example.com", "Ola")
+	service := NewAccountService()
+
+	if err := service.CancelDeletion(context.Background(), user.ID); !errors.Is(err, ErrNoDeletionScheduled) {
+		t.Errorf("Expected ErrNoDeletionScheduled, got %v", err)
+	}
+
+	scheduledAt, _ := service.ScheduleDeletion(context.Background(), user.ID)
+	if err := service.CancelDeletion(context.Background(), user.ID); err != nil {
+		t.Fatalf("CancelDeletion returned error: %v", err)
+	}
+	if purged, _ := service.PurgeDueAccounts(scheduledAt.Add(time.Minute)); purged != 0 {
+		t.Errorf("Expected a cancelled deletion not to be purged, got %d", purged)
+	}
+}
+
+func TestExportUserData(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	user, _ := NewUserService().GetOrCreateUser(context.Background(), "auth0", "auth0|pat", "pat@example.com", "Pat")
+	db.Create(&models.Category{Name: "Crafts", Slug: "crafts"})
+	db.Create(&models.Skill{Title: "Knitting", Category: "Crafts", UserID: user.ID, Price: 10, Duration: 30})
+
+	export, err := NewAccountService().ExportUserData(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("ExportUserData returned error: %v", err)
+	}
+	if export.Profile.ID != user.ID || len(export.Identities) != 1 || len(export.Skills) != 1 {
+		t.Errorf("Expected profile, identity and skill in export, got %+v", export)
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"skillswap/internal/cache"
+	"skillswap/internal/database"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"time"
+)
+
+// CategoryCacheTTL is how long the category tree and its skill counts are
+// served before they are counted again
+const CategoryCacheTTL = time.Minute
+
+// categoryTrees holds the one tree, under the empty key, so every browse
+// page doesn't count skills again
+var categoryTrees = cache.New[string, *models.CategoryTree](CategoryCacheTTL)
+
+type CategoryService struct{}
+
+func NewCategoryService() *CategoryService {
+	return &CategoryService{}
+}
+
+// GetCategoryTree returns every category nested under its parent, each with
+// the number of active listings in it and its subcategories
+func (s *CategoryService) GetCategoryTree(ctx context.Context) (*models.CategoryTree, error) {
+	if tree, ok := categoryTrees.Get(""); ok {
+		return tree, nil
+	}
+
+	repo := repository.NewCategoryRepository(database.DB.WithContext(ctx))
+	categories, err := repo.ListCategories()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := repo.CountActiveSkills()
+	if err != nil {
+		return nil, err
+	}
+
+	// Categories arrive in name order, so each list of children is too
+	children := map[string][]models.Category{}
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	var build func(categories []models.Category) []models.CategoryNode
+	build = func(categories []models.Category) []models.CategoryNode {
+		nodes := make([]models.CategoryNode, len(categories))
+		for i, category := range categories {
+			node := models.CategoryNode{
+				ID:         category.ID,
+				Name:       category.Name,
+				Slug:       category.Slug,
+				ParentID:   category.ParentID,
+				SkillCount: counts[category.ID],
+				Children:   build(children[category.ID]),
+			}
+			for _, child := range node.Children {
+				node.SkillCount += child.SkillCount
+			}
+			nodes[i] = node
+		}
+		return nodes
+	}
+
+	tree := &models.CategoryTree{Categories: build(roots)}
+	categoryTrees.Set("", tree)
+	return tree, nil
+}
+
+// ClearCategoryCache drops the cached category tree, e.g. once an admin has
+// changed it or between tests
+func ClearCategoryCache() {
+	categoryTrees.Clear()
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"skillswap/internal/cache"
+	"skillswap/internal/database"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"time"
+)
+
+// LeaderboardCacheTTL is how long a computed leaderboard is served before it
+// is computed again
+const LeaderboardCacheTTL = 5 * time.Minute
+
+// leaderboards is shared by every request so each query is computed at most
+// once per TTL in this process
+var leaderboards = cache.New[models.LeaderboardQuery, *models.Leaderboard](LeaderboardCacheTTL)
+
+type LeaderboardService struct{}
+
+func NewLeaderboardService() *LeaderboardService {
+	return &LeaderboardService{}
+}
+
+// GetLeaderboard returns the leaderboard for query, from the cache when it
+// was computed within LeaderboardCacheTTL
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, query models.LeaderboardQuery) (*models.Leaderboard, error) {
+	if leaderboard, ok := leaderboards.Get(query); ok {
+		return leaderboard, nil
+	}
+
+	now := time.Now().UTC()
+	repo := repository.NewLeaderboardRepository(database.DB.WithContext(ctx))
+	leaderboard := &models.Leaderboard{
+		Board:       query.Board,
+		Category:    query.Category,
+		Location:    query.Location,
+		GeneratedAt: now,
+	}
+
+	var err error
+	switch query.Board {
+	case models.BoardRating:
+		leaderboard.MinReviews = query.MinReviews
+		leaderboard.Entries, err = repo.TopRated(query)
+	default:
+		leaderboard.Window = query.Window
+		leaderboard.Entries, err = repo.TopByPoints(query, query.Window.Since(now))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboards.Set(query, leaderboard)
+	return leaderboard, nil
+}
+
+// ClearLeaderboardCache drops every cached leaderboard, e.g. between tests
+func ClearLeaderboardCache() {
+	leaderboards.Clear()
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"skillswap/internal/database"
+	"skillswap/internal/media"
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+)
+
+// ErrNotSkillOwner is returned when someone changes the gallery of a skill
+// listed by another user
+var ErrNotSkillOwner = errors.New("skill belongs to another user")
+
+type MediaService struct{}
+
+func NewMediaService() *MediaService {
+	return &MediaService{}
+}
+
+// SetAvatar processes an uploaded avatar and makes it the user's, removing
+// the one it replaces if that was uploaded too. host is the scheme and host
+// the upload was made to, which links start with when no base URL is set.
+func (s *MediaService) SetAvatar(ctx context.Context, userID, host string, data []byte) (*models.UploadedImage, error) {
+	upload, err := media.Save(ctx, media.Avatar, userID, data)
+	if err != nil {
+		return nil, err
+	}
+	image := uploadedImage(host, upload)
+
+	userRepo := repository.NewUserRepository(database.DB.WithContext(ctx))
+	previous, err := userRepo.SetAvatar(userID, image.URL, image.ThumbnailURL)
+	if err != nil {
+		removeMedia(ctx, upload.Key)
+		return nil, err
+	}
+	ForgetUser(userID)
+
+	// The same file uploaded again is stored under the same key
+	if key, ok := media.KeyFromURL(previous); ok && key != upload.Key {
+		removeMedia(ctx, key)
+	}
+	return image, nil
+}
+
+// RemoveAvatar clears the user's avatar, deleting it from storage if it was uploaded
+func (s *MediaService) RemoveAvatar(ctx context.Context, userID string) error {
+	userRepo := repository.NewUserRepository(database.DB.WithContext(ctx))
+	previous, err := userRepo.SetAvatar(userID, "", "")
+	if err != nil {
+		return err
+	}
+	ForgetUser(userID)
+
+	if key, ok := media.KeyFromURL(previous); ok {
+		removeMedia(ctx, key)
+	}
+	return nil
+}
+
+// AddSkillImage processes an uploaded image and adds it to the gallery of a
+// skill the user lists
+func (s *MediaService) AddSkillImage(ctx context.Context, userID, skillID, host string, data []byte) (*models.SkillImage, error) {
+	skillRepo := repository.NewSkillRepository(database.DB.WithContext(ctx))
+	skill, err := skillRepo.GetSkillByID(skillID)
+	if err != nil {
+		return nil, err
+	}
+	if skill.UserID != userID {
+		return nil, ErrNotSkillOwner
+	}
+
+	upload, err := media.Save(ctx, media.SkillImage, skillID, data)
+	if err != nil {
+		return nil, err
+	}
+	uploaded := uploadedImage(host, upload)
+	image := &models.SkillImage{
+		SkillID:      skillID,
+		URL:          uploaded.URL,
+		ThumbnailURL: uploaded.ThumbnailURL,
+		Key:          upload.Key,
+		Width:        upload.Width,
+		Height:       upload.Height,
+	}
+
+	if err := skillRepo.AddSkillImage(image); err != nil {
+		// A duplicate shares its key with the image already in the gallery
+		if !errors.Is(err, repository.ErrDuplicateImage) {
+			removeMedia(ctx, upload.Key)
+		}
+		return nil, err
+	}
+	return image, nil
+}
+
+// RemoveSkillImage deletes an image from the gallery of a skill the user lists
+func (s *MediaService) RemoveSkillImage(ctx context.Context, userID, skillID, imageID string) error {
+	skillRepo := repository.NewSkillRepository(database.DB.WithContext(ctx))
+	skill, err := skillRepo.GetSkillByID(skillID)
+	if err != nil {
+		return err
+	}
+	if skill.UserID != userID {
+		return ErrNotSkillOwner
+	}
+
+	image, err := skillRepo.DeleteSkillImage(skillID, imageID)
+	if err != nil {
+		return err
+	}
+	removeMedia(ctx, image.Key)
+	return nil
+}
+
+func uploadedImage(host string, upload *media.Upload) *models.UploadedImage {
+	return &models.UploadedImage{
+		URL:          media.URL(host, upload.Key),
+		ThumbnailURL: media.URL(host, upload.ThumbKey),
+		Width:        upload.Width,
+		Height:       upload.Height,
+	}
+}
+
+// removeMedia deletes an upload nothing refers to any more. It only logs
+// failures: the database change has been made, and a stray file is harmless.
+func removeMedia(ctx context.Context, key string) {
+	if err := media.Remove(ctx, key); err != nil {
+		slog.Error("Failed to remove media", "key", key, "error", err)
+	}
+}
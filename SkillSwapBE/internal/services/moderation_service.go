@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"skillswap/internal/audit"
+	"skillswap/internal/database"
+	"skillswap/internal/models"
+	"skillswap/internal/moderation"
+	"skillswap/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrAlreadyReported is returned when a user reports the same content twice
+	ErrAlreadyReported = errors.New("you have already reported this")
+	// ErrReportResolved is returned when resolving a report that isn't open
+	ErrReportResolved = errors.New("report has already been resolved")
+)
+
+// prescreenReason prefixes the reason on reports filed by the pre-screen
+const prescreenReason = "Held by automatic pre-screen"
+
+type ModerationService struct{}
+
+func NewModerationService() *ModerationService {
+	return &ModerationService{}
+}
+
+// ReportContent files a user's report against a skill, user or review
+func (s *ModerationService) ReportContent(ctx context.Context, reporterID string, targetType models.ReportTargetType, targetID string, req models.CreateReportRequest) (*models.Report, error) {
+	db := database.DB.WithContext(ctx)
+	if err := s.targetExists(db, targetType, targetID); err != nil {
+		return nil, err
+	}
+
+	reportRepo := repository.NewReportRepository(db)
+	if _, err := reportRepo.GetOpenReport(reporterID, targetType, targetID); err == nil {
+		return nil, ErrAlreadyReported
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	report := &models.Report{
+		ReporterID: &reporterID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     models.ReportOpen,
+	}
+	if err := reportRepo.CreateReport(report); err != nil {
+		return nil, fmt.Errorf("failed to create report: %v", err)
+	}
+
+	slog.InfoContext(ctx, "Content reported", "reporter_id", reporterID, "target_type", targetType, "target_id", targetID, "reason", req.Reason)
+	return report, nil
+}
+
+// HoldProfileChanges pre-screens the free-text fields of a profile update.
+// Fields that need a moderator are cleared from updateReq and filed as reports
+// holding the new value, which is applied only if the report is dismissed.
+// The reports are written with db, so they can share the update's transaction.
+func (s *ModerationService) HoldProfileChanges(ctx context.Context, db *gorm.DB, userID string, updateReq *models.UpdateUserRequest) ([]models.Report, error) {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"full_name", &updateReq.FullName},
+		{"location", &updateReq.Location},
+		{"bio", &updateReq.Bio},
+	}
+
+	var current *models.User
+	var held []models.Report
+	for _, f := range fields {
+		field, value := f.name, f.value
+		reason := moderation.Screen(*value)
+		if reason == "" {
+			continue
+		}
+
+		// The profile keeps its current value while the change is held
+		if current == nil {
+			current = &models.User{}
+			if err := db.Select("full_name", "location", "bio").First(current, "id = ?", userID).Error; err != nil {
+				return nil, fmt.Errorf("failed to hold %s for review: %v", field, err)
+			}
+		}
+		var previous string
+		switch field {
+		case "full_name":
+			previous = current.FullName
+		case "location":
+			previous = current.Location
+		case "bio":
+			previous = current.Bio
+		}
+
+		report := models.Report{
+			TargetType:  models.ReportTargetUser,
+			TargetID:    userID,
+			Reason:      prescreenReason,
+			Details:     fmt.Sprintf("%s %s", field, reason),
+			Status:      models.ReportOpen,
+			HeldField:   field,
+			HeldContent: *value,
+			HeldOver:    previous,
+		}
+		if err := repository.NewReportRepository(db).CreateReport(&report); err != nil {
+			return nil, fmt.Errorf("failed to hold %s for review: %v", field, err)
+		}
+
+		slog.InfoContext(ctx, "Held profile change for review", "field", field, "target_user_id", userID, "reason", reason)
+		*value = ""
+		held = append(held, report)
+	}
+
+	return held, nil
+}
+
+// ResolveReport closes a report as actioned or dismissed. Dismissing a report
+// that holds content applies the held content.
+func (s *ModerationService) ResolveReport(ctx context.Context, reportID, moderatorID string, req models.ResolveReportRequest) (*models.Report, error) {
+	var report *models.Report
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reportRepo := repository.NewReportRepository(tx)
+
+		var err error
+		report, err = reportRepo.GetReportForUpdate(reportID)
+		if err != nil {
+			return err
+		}
+		if report.Status != models.ReportOpen {
+			return ErrReportResolved
+		}
+
+		before := *report
+		now := time.Now()
+		report.Status = req.Status
+		report.Resolution = req.Resolution
+		report.ResolvedBy = &moderatorID
+		report.ResolvedAt = &now
+		if resolved, err := reportRepo.ResolveReport(report); err != nil {
+			return err
+		} else if !resolved {
+			return ErrReportResolved
+		}
+		if err := audit.Record(tx, "report.resolve", "report", report.ID, before, report); err != nil {
+			return err
+		}
+
+		if req.Status == models.ReportDismissed && report.HeldField != "" && report.TargetType == models.ReportTargetUser {
+			return s.applyHeldContent(ctx, tx, report)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if report.TargetType == models.ReportTargetUser {
+		ForgetUser(report.TargetID)
+	}
+
+	slog.InfoContext(ctx, "Resolved report", "report_id", reportID, "status", req.Status, "moderator_id", moderatorID)
+	return report, nil
+}
+
+// applyHeldContent writes a held profile field once a moderator has cleared
+// it, unless the user has changed the field since it was held
+func (s *ModerationService) applyHeldContent(ctx context.Context, tx *gorm.DB, report *models.Report) error {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", report.TargetID).Error; err != nil {
+		return err
+	}
+
+	before := user
+	result := tx.Model(&user).
+		Where(clause.Eq{Column: clause.Column{Name: report.HeldField}, Value: report.HeldOver}).
+		Update(report.HeldField, report.HeldContent)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		slog.InfoContext(ctx, "Held content is stale, leaving profile as it is", "report_id", report.ID, "field", report.HeldField)
+		return nil
+	}
+	return audit.Record(tx, "user.update", "user", user.ID, before, user)
+}
+
+// targetExists returns gorm.ErrRecordNotFound unless the reported content
+// exists, including for an ID that isn't a UUID
+func (s *ModerationService) targetExists(db *gorm.DB, targetType models.ReportTargetType, targetID string) error {
+	if !repository.ValidID(targetID) {
+		return gorm.ErrRecordNotFound
+	}
+
+	var target interface{}
+	switch targetType {
+	case models.ReportTargetSkill:
+		target = &models.Skill{}
+	case models.ReportTargetUser:
+		target = &models.User{}
+	case models.ReportTargetReview:
+		target = &models.Review{}
+	default:
+		return fmt.Errorf("unknown report target %q", targetType)
+	}
+	return db.Select("id").First(target, "id = ?", targetID).Error
+}
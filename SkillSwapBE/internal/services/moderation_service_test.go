@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"skillswap/internal/models"
+	"skillswap/internal/moderation"
+	"skillswap/internal/testutil"
+
+	"gorm.io/gorm"
+)
+
+func TestHeldProfileChangeAppliedWhenDismissed(t *testing.T) {
+	testutil.NewTestDB(t)
+	moderation.SetScreener(moderation.NewWordList("casino"))
+	t.Cleanup(func() { moderation.SetScreener(moderation.Chain{}) })
+
+	users := NewUserService()
+	service := NewModerationService()
+	user, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|gus", "gus@example.com", "Gus")
+	mod, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|mod", "mod@example.com", "Mod")
+
+	updateReq := models.UpdateUserRequest{FullName: "Gus Grant", Bio: "I teach casino games"}
+	held, err := users.UpdateProfile(context.Background(), user.ID, &updateReq)
+	if err != nil {
+		t.Fatalf("UpdateProfile returned error: %v", err)
+	}
+	if len(held) != 1 || held[0].HeldField != "bio" {
+		t.Fatalf("Expected the bio to be held, got %+v", held)
+	}
+	if updateReq.Bio != "" || updateReq.FullName != "Gus Grant" {
+		t.Errorf("Expected only the bio to be cleared, got %+v", updateReq)
+	}
+
+	if _, err := service.ResolveReport(context.Background(), held[0].ID, mod.ID, models.ResolveReportRequest{Status: models.ReportDismissed}); err != nil {
+		t.Fatalf("ResolveReport returned error: %v", err)
+	}
+	updated, _ := users.GetUserByID(context.Background(), user.ID)
+	if updated.Bio != "I teach casino games" {
+		t.Errorf("Expected held bio to be applied on dismissal, got %q", updated.Bio)
+	}
+
+	_, err = service.ResolveReport(context.Background(), held[0].ID, mod.ID, models.ResolveReportRequest{Status: models.ReportActioned})
+	if !errors.Is(err, ErrReportResolved) {
+		t.Errorf("Expected ErrReportResolved, got %v", err)
+	}
+}
+
+func TestHeldProfileChangeSkippedOnceFieldChanges(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	moderation.SetScreener(moderation.NewWordList("casino"))
+	t.Cleanup(func() { moderation.SetScreener(moderation.Chain{}) })
+
+	users := NewUserService()
+	service := NewModerationService()
+	user, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|gus", "gus@example.com", "Gus")
+	mod, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|mod", "mod@example.com", "Mod")
+
+	updateReq := models.UpdateUserRequest{Bio: "I teach casino games"}
+	held, err := users.UpdateProfile(context.Background(), user.ID, &updateReq)
+	if err != nil || len(held) != 1 {
+		t.Fatalf("Expected the bio to be held, got %+v, %v", held, err)
+	}
+
+	// A later edit that passed the pre-screen outranks the held one
+	db.Model(&models.User{}).Where("id = ?", user.ID).Update("bio", "I teach chess")
+	if _, err := service.ResolveReport(context.Background(), held[0].ID, mod.ID, models.ResolveReportRequest{Status: models.ReportDismissed}); err != nil {
+		t.Fatalf("ResolveReport returned error: %v", err)
+	}
+	updated, _ := users.GetUserByID(context.Background(), user.ID)
+	if updated.Bio != "I teach chess" {
+		t.Errorf("Expected the later bio to be kept, got %q", updated.Bio)
+	}
+}
+
+func TestHeldLocationKeepsCurrentValue(t *testing.T) {
+	testutil.NewTestDB(t)
+	moderation.SetScreener(moderation.NewWordList("casino"))
+	t.Cleanup(func() { moderation.SetScreener(moderation.Chain{}) })
+
+	users := NewUserService()
+	user, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|gus", "gus@example.com", "Gus")
+
+	updateReq := models.UpdateUserRequest{FullName: "Gus Grant", Location: "Above the casino"}
+	held, err := users.UpdateProfile(context.Background(), user.ID, &updateReq)
+	if err != nil {
+		t.Fatalf("UpdateProfile returned error: %v", err)
+	}
+	if len(held) != 1 || held[0].HeldField != "location" || held[0].HeldContent != "Above the casino" {
+		t.Fatalf("Expected the location to be held, got %+v", held)
+	}
+	updated, _ := users.GetUserByID(context.Background(), user.ID)
+	if updated.FullName != "Gus Grant" || updated.Location != "" {
+		t.Errorf("Expected the name to change and the location to wait, got %q, %q", updated.FullName, updated.Location)
+	}
+}
+
+func TestReportContentRejectsDuplicates(t *testing.T) {
+	testutil.NewTestDB(t)
+	users := NewUserService()
+	service := NewModerationService()
+	reporter, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|ivy", "ivy@example.com", "Ivy")
+	target, _ := users.GetOrCreateUser(context.Background(), "auth0", "auth0|jon", "jon@example.com", "Jon")
+
+	req := models.CreateReportRequest{Reason: "Harassment"}
+	if _, err := service.ReportContent(context.Background(), reporter.ID, models.ReportTargetUser, target.ID, req); err != nil {
+		t.Fatalf("ReportContent returned error: %v", err)
+	}
+	if _, err := service.ReportContent(context.Background(), reporter.ID, models.ReportTargetUser, target.ID, req); !errors.Is(err, ErrAlreadyReported) {
+		t.Errorf("Expected ErrAlreadyReported, got %v", err)
+	}
+	if _, err := service.ReportContent(context.Background(), reporter.ID, models.ReportTargetSkill, target.ID, req); err == nil {
+		t.Error("Expected error when reporting a skill that doesn't exist")
+	}
+	if _, err := service.ReportContent(context.Background(), reporter.ID, models.ReportTargetUser, "not-a-uuid", req); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound for a malformed ID, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"skillswap/internal/cache"
+	"skillswap/internal/models"
+	"time"
+)
+
+// UserCacheTTL is how long a login's user and suspension are served from
+// memory. Changes made through this process drop the entry at once; other
+// API instances see them within the TTL.
+const UserCacheTTL = time.Minute
+
+type loginKey struct {
+	provider, subject string
+}
+
+// cachedLogin maps a login to its user, for the name claim it was cached with
+type cachedLogin struct {
+	userID string
+	name   string
+}
+
+// cachedUser is what every protected request needs to know about its user
+type cachedUser struct {
+	user       models.User
+	suspension *models.Suspension
+}
+
+// loginCache and userCache are shared by every request. They are kept apart so
+// ForgetUser can drop a user without knowing their logins.
+var (
+	loginCache = cache.New[loginKey, cachedLogin](UserCacheTTL)
+	userCache  = cache.New[string, cachedUser](UserCacheTTL)
+)
+
+// GetLoginUser returns the user for a login and the suspension blocking them,
+// if any, as GetOrCreateUser and GetActiveSuspension would. Results are
+// cached for UserCacheTTL, so most requests don't touch the database.
+func (s *UserService) GetLoginUser(ctx context.Context, provider, subject, email, name string) (*models.User, *models.Suspension, error) {
+	key := loginKey{provider, subject}
+	if login, ok := loginCache.Get(key); ok && login.name == name {
+		if cached, ok := userCache.Get(login.userID); ok {
+			user := cached.user // Each request gets its own copy
+			suspension := cached.suspension
+			if suspension != nil && suspension.ExpiresAt != nil && !suspension.ExpiresAt.After(time.Now()) {
+				suspension = nil
+			}
+			return &user, suspension, nil
+		}
+	}
+
+	user, err := s.GetOrCreateUser(ctx, provider, subject, email, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	suspension, err := s.GetActiveSuspension(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loginCache.Set(key, cachedLogin{userID: user.ID, name: name})
+	userCache.Set(user.ID, cachedUser{user: *user, suspension: suspension})
+	return user, suspension, nil
+}
+
+// ForgetUser drops a user from the login cache after they change, so the
+// next request reads them again
+func ForgetUser(userID string) {
+	userCache.Delete(userID)
+}
+
+// ClearUserCache drops every cached login and user, e.g. between tests
+func ClearUserCache() {
+	loginCache.Clear()
+	userCache.Clear()
+}
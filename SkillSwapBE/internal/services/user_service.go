@@ -1,98 +1,142 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"skillswap/internal/database"
+	"skillswap/internal/metrics"
 	"skillswap/internal/models"
+	"skillswap/internal/repository"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// ErrIdentityInUse is returned when a login is already linked to another user
+var ErrIdentityInUse = errors.New("identity is already linked to another user")
+
 type UserService struct{}
 
 func NewUserService() *UserService {
 	return &UserService{}
 }
 
-// GetOrCreateUser retrieves an existing user or creates a new one from Auth0 claims
-func (s *UserService) GetOrCreateUser(auth0ID, email, name string) (*models.User, error) {
-	// First, try to find existing user by Auth0 ID
+// GetOrCreateUser retrieves the user linked to an identity provider login,
+// creating a new user and identity from the token claims on first login. It
+// is safe to call in parallel for the same login: one call creates the user
+// and the others return it. A name changed at the identity provider is
+// copied onto the user.
+func (s *UserService) GetOrCreateUser(ctx context.Context, provider, subject, email, name string) (*models.User, error) {
+	db := database.DB.WithContext(ctx)
+
+	// First, try to find existing user by linked identity
+	user, err := s.loginUser(ctx, db, provider, subject, name)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	// User doesn't exist, create a new one
+	slog.DebugContext(ctx, "Creating new user", "provider", provider, "subject", subject)
+	user, err = s.createUser(db, provider, subject, email, name)
+	if errors.Is(err, repository.ErrIdentityExists) {
+		// A parallel request for the same first login created the user first
+		slog.DebugContext(ctx, "Lost first login race", "provider", provider, "subject", subject)
+		return s.loginUser(ctx, db, provider, subject, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+
+	metrics.UsersCreated.Inc()
+	slog.InfoContext(ctx, "Created new user", "user_id", user.ID, "provider", provider, "subject", subject)
+	return user, nil
+}
+
+// loginUser retrieves the user linked to a login and refreshes their name
+// from the claims. It returns gorm.ErrRecordNotFound for an unknown login.
+func (s *UserService) loginUser(ctx context.Context, db *gorm.DB, provider, subject, name string) (*models.User, error) {
+	identityRepo := repository.NewIdentityRepository(db)
+	identity, err := identityRepo.GetIdentity(provider, subject)
+	if err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	result := database.DB.Where("auth0_id = ?", auth0ID).First(&user)
-	
-	if result.Error == nil {
-		// User exists, return it
-		log.Printf("Found existing user with Auth0 ID: %s", user.Auth0ID)
+	if err := db.First(&user, "id = ?", identity.UserID).Error; err != nil {
+		return nil, err
+	}
+	slog.DebugContext(ctx, "Found existing user", "provider", provider, "subject", subject, "user_id", user.ID)
+
+	if name == "" || (identity.ClaimedName != nil && *identity.ClaimedName == name) {
 		return &user, nil
 	}
+	renamed, err := identityRepo.UpdateClaimedName(identity, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh claims: %v", err)
+	}
+	if renamed {
+		user.FullName = name
+		slog.InfoContext(ctx, "Refreshed name from identity provider", "user_id", user.ID, "provider", provider)
+	}
+	return &user, nil
+}
 
-	// User doesn't exist, create a new one
-	log.Printf("Creating new user for Auth0 ID: %s", auth0ID)
-	
-	// Generate a username from email
-	username := s.generateUsername(email)
-	
+// createUser creates a user and their first login from the token claims. A
+// concurrent signup can take the suggested username between the check and
+// the insert, so it suggests another and tries again.
+func (s *UserService) createUser(db *gorm.DB, provider, subject, email, name string) (*models.User, error) {
 	// Extract full name, fallback to email local part if name is empty
 	fullName := name
 	if fullName == "" && email != "" {
 		fullName = strings.Split(email, "@")[0]
 	}
 
-	newUser := models.User{
-		Auth0ID:     auth0ID,
-		Username:    username,
-		FullName:    fullName,
-		Points:      0,
-		Rank:        models.Novice,
-		Rating:      0.0,
-		ReviewCount: 0,
-	}
+	usernameService := NewUsernameService()
+	userRepo := repository.NewUserRepository(db)
+	for attempt := 1; ; attempt++ {
+		username, err := usernameService.SuggestUsername(db, email)
+		if err != nil {
+			return nil, err
+		}
 
-	// Create user in database
-	if err := database.DB.Create(&newUser).Error; err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		user := &models.User{
+			Username:    username,
+			FullName:    fullName,
+			Points:      0,
+			Rank:        models.Novice,
+			Rating:      0.0,
+			ReviewCount: 0,
+		}
+		identity := &models.UserIdentity{Provider: provider, Subject: subject, ClaimedName: &name}
+		err = userRepo.CreateUserWithIdentity(user, identity)
+		if !errors.Is(err, gorm.ErrDuplicatedKey) || attempt == maxUsernameAttempts {
+			return user, err
+		}
 	}
-
-	log.Printf("Successfully created new user with Auth0 ID: %s", newUser.Auth0ID)
-	return &newUser, nil
 }
 
-// generateUsername creates a unique username from email
-func (s *UserService) generateUsername(email string) string {
-	// Start with the local part of email
-	baseName := strings.Split(email, "@")[0]
-	
-	// Clean up the username (remove special characters, convert to lowercase)
-	baseName = strings.ToLower(baseName)
-	baseName = strings.ReplaceAll(baseName, ".", "")
-	baseName = strings.ReplaceAll(baseName, "+", "")
-	baseName = strings.ReplaceAll(baseName, "-", "")
-	
-	// Check if username is available
-	username := baseName
-	counter := 1
-	
-	for {
-		var existingUser models.User
-		result := database.DB.Where("username = ?", username).First(&existingUser)
-		
-		if result.Error != nil {
-			// Username is available
-			break
-		}
-		
-		// Username exists, try with a number suffix
-		username = fmt.Sprintf("%s%d", baseName, counter)
-		counter++
-		
-		// Prevent infinite loop
-		if counter > 1000 {
-			username = fmt.Sprintf("%s_%d", baseName, models.GenerateRandomInt())
-			break
+// LinkIdentity attaches another provider login to an existing user
+func (s *UserService) LinkIdentity(ctx context.Context, userID, provider, subject string) (*models.UserIdentity, error) {
+	identityRepo := repository.NewIdentityRepository(database.DB.WithContext(ctx))
+
+	existing, err := identityRepo.GetIdentity(provider, subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityInUse
 		}
+		return existing, nil
 	}
-	
-	return username
+
+	identity := &models.UserIdentity{UserID: userID, Provider: provider, Subject: subject}
+	if err := identityRepo.CreateIdentity(identity); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %v", err)
+	}
+
+	slog.InfoContext(ctx, "Linked identity", "provider", provider, "subject", subject, "target_user_id", userID)
+	return identity, nil
 }
 
 // UpdateUserProfile updates user profile information
@@ -109,26 +153,112 @@ func (s *UserService) UpdateUserProfile(userID string, updates map[string]interf
 	return nil
 }
 
-// GetUserByAuth0ID retrieves a user by their Auth0 ID
-func (s *UserService) GetUserByAuth0ID(auth0ID string) (*models.User, error) {
-	var user models.User
-	result := database.DB.Where("auth0_id = ?", auth0ID).First(&user)
-	
-	if result.Error != nil {
-		return nil, fmt.Errorf("user not found: %v", result.Error)
+// UpdateProfile applies a user's own profile update, renaming them when
+// updateReq has a username. Free text that the pre-screen flags is held for a
+// moderator rather than applied, and the held reports are returned. The
+// rename, the held reports and the update share one transaction, so a taken
+// username or any other failure leaves the profile as it was.
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, updateReq *models.UpdateUserRequest) ([]models.Report, error) {
+	if updateReq.Username != "" {
+		if err := ValidateUsername(updateReq.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	var held []models.Report
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userRepo := repository.NewUserRepository(tx)
+		if updateReq.Username != "" {
+			if err := userRepo.ChangeUsername(userID, updateReq.Username, heldSince()); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		held, err = NewModerationService().HoldProfileChanges(ctx, tx, userID, updateReq)
+		if err != nil {
+			return err
+		}
+		return userRepo.UpdateUser(userID, updateReq)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ForgetUser(userID)
+	return held, nil
+}
+
+// GetUserByIdentity retrieves a user by a linked identity provider login
+func (s *UserService) GetUserByIdentity(provider, subject string) (*models.User, error) {
+	user, err := repository.NewUserRepository(database.DB).GetUserByIdentity(provider, subject)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
 	}
 	
-	return &user, nil
+	return user, nil
 }
 
 // GetUserByID retrieves a user by their UUID
-func (s *UserService) GetUserByID(userID string) (*models.User, error) {
+func (s *UserService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	var user models.User
-	result := database.DB.Where("id = ?", userID).First(&user)
+	result := database.DB.WithContext(ctx).Where("id = ?", userID).First(&user)
 	
 	if result.Error != nil {
-		return nil, fmt.Errorf("user not found: %v", result.Error)
+		return nil, fmt.Errorf("failed to get user %s: %w", userID, result.Error)
 	}
 	
 	return &user, nil
+}
+
+// SuspendUser blocks a user from the protected API until expiresAt, or for good
+// when expiresAt is nil. Their pending bookings are cancelled with a full refund.
+func (s *UserService) SuspendUser(ctx context.Context, userID, moderatorID, reason string, expiresAt *time.Time) (*models.Suspension, error) {
+	if err := database.DB.WithContext(ctx).First(&models.User{}, "id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+
+	suspension := &models.Suspension{
+		UserID:      userID,
+		ModeratorID: moderatorID,
+		Reason:      reason,
+		ExpiresAt:   expiresAt,
+	}
+
+	var cancelled int64
+	err := database.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewSuspensionRepository(tx).CreateSuspension(suspension); err != nil {
+			return err
+		}
+
+		var err error
+		cancelled, err = repository.NewBookingRepository(tx).CancelPendingBookings(userID, "Account suspended")
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suspend user: %v", err)
+	}
+	ForgetUser(userID)
+
+	slog.InfoContext(ctx, "Suspended user", "target_user_id", userID, "moderator_id", moderatorID, "cancelled_bookings", cancelled, "reason", reason)
+	return suspension, nil
+}
+
+// LiftSuspension ends a user's active suspensions early
+func (s *UserService) LiftSuspension(ctx context.Context, userID, moderatorID string) error {
+	if err := repository.NewSuspensionRepository(database.DB.WithContext(ctx)).LiftSuspensions(userID, moderatorID); err != nil {
+		return err
+	}
+	ForgetUser(userID)
+
+	slog.InfoContext(ctx, "Lifted suspension", "target_user_id", userID, "moderator_id", moderatorID)
+	return nil
+}
+
+// GetActiveSuspension returns the suspension blocking a user, or nil if there is none
+func (s *UserService) GetActiveSuspension(ctx context.Context, userID string) (*models.Suspension, error) {
+	suspension, err := repository.NewSuspensionRepository(database.DB.WithContext(ctx)).GetActiveSuspension(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return suspension, err
 }
\ No newline at end of file
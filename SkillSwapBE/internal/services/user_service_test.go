@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"skillswap/internal/models"
+	"skillswap/internal/repository"
+	"skillswap/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.RunWithPostgres(m))
+}
+
+func TestGetOrCreateUserCreatesOnce(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	service := NewUserService()
+
+	created, err := service.GetOrCreateUser(context.Background(), "auth0", "auth0|dana", "dana.smith@example.com", "Dana Smith")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	if created.Username != "danasmith" {
+		t.Errorf("Expected username 'danasmith', got '%s'", created.Username)
+	}
+	if created.FullName != "Dana Smith" {
+		t.Errorf("Expected full name 'Dana Smith', got '%s'", created.FullName)
+	}
+	if created.Rank != models.Novice {
+		t.Errorf("Expected rank %s, got %s", models.Novice, created.Rank)
+	}
+
+	found, err := service.GetOrCreateUser(context.Background(), "auth0", "auth0|dana", "dana.smith@example.com", "Dana Smith")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	if found.ID != created.ID {
+		t.Errorf("Expected existing user %s, got %s", created.ID, found.ID)
+	}
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 user row, got %d", count)
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	testutil.NewTestDB(t)
+	service := NewUserService()
+
+	hana, err := service.GetOrCreateUser(context.Background(), "auth0", "auth0|hana", "hana@example.com", "Hana")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	ivan, err := service.GetOrCreateUser(context.Background(), "auth0", "auth0|ivan", "ivan@example.com", "Ivan")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+
+	if _, err := service.LinkIdentity(context.Background(), hana.ID, "corp", "hana@corp"); err != nil {
+		t.Fatalf("LinkIdentity returned error: %v", err)
+	}
+
+	// Logging in through the linked provider finds the same user
+	found, err := service.GetOrCreateUser(context.Background(), "corp", "hana@corp", "hana@corp.example.com", "Hana")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	if found.ID != hana.ID {
+		t.Errorf("Expected linked login to resolve to %s, got %s", hana.ID, found.ID)
+	}
+
+	if _, err := service.LinkIdentity(context.Background(), ivan.ID, "corp", "hana@corp"); !errors.Is(err, ErrIdentityInUse) {
+		t.Errorf("Expected ErrIdentityInUse, got %v", err)
+	}
+}
+
+func TestGetOrCreateUserUsernameCollision(t *testing.T) {
+	testutil.NewTestDB(t)
+	service := NewUserService()
+
+	first, err := service.GetOrCreateUser(context.Background(), "auth0", "auth0|sam1", "sam@example.com", "")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	second, err := service.GetOrCreateUser(context.Background(), "auth0", "google-oauth2|sam2", "sam@example.org", "")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+
+	if first.Username != "sam" || second.Username != "sam1" {
+		t.Errorf("Expected usernames 'sam' and 'sam1', got '%s' and '%s'", first.Username, second.Username)
+	}
+	if second.FullName != "sam" {
+		t.Errorf("Expected full name to fall back to email local part, got '%s'", second.FullName)
+	}
+}
+
+func TestGetOrCreateUserParallelFirstLogin(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	service := NewUserService()
+
+	// The SPA fires several requests at once on first login
+	const requests = 8
+	ids := make([]string, requests)
+	errs := make([]error, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user, err := service.GetOrCreateUser(context.Background(), "auth0", "auth0|kai", "kai@example.com", "Kai")
+			if err == nil {
+				ids[i] = user.ID
+			}
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range ids {
+		if errs[i] != nil {
+			t.Fatalf("GetOrCreateUser returned error: %v", errs[i])
+		}
+		if ids[i] != ids[0] {
+			t.Errorf("Expected every request to get user %s, got %s", ids[0], ids[i])
+		}
+	}
+
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 user row, got %d", count)
+	}
+}
+
+func TestGetOrCreateUserRefreshesName(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	service := NewUserService()
+	ctx := context.Background()
+
+	user, err := service.GetOrCreateUser(ctx, "auth0", "auth0|lou", "lou@example.com", "Lou")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+
+	// A name edited on SkillSwap sticks while the provider's stays the same
+	if err := repository.NewUserRepository(db).UpdateUser(user.ID, &models.UpdateUserRequest{FullName: "Louise"}); err != nil {
+		t.Fatalf("UpdateUser returned error: %v", err)
+	}
+	user, err = service.GetOrCreateUser(ctx, "auth0", "auth0|lou", "lou@example.com", "Lou")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	if user.FullName != "Louise" {
+		t.Errorf("Expected the edited name to stick, got '%s'", user.FullName)
+	}
+
+	// ...until the provider's changes
+	user, err = service.GetOrCreateUser(ctx, "auth0", "auth0|lou", "lou@example.com", "Lou Reed")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	var stored models.User
+	db.First(&stored, "id = ?", user.ID)
+	if user.FullName != "Lou Reed" || stored.FullName != "Lou Reed" {
+		t.Errorf("Expected the provider's new name, got '%s' and stored '%s'", user.FullName, stored.FullName)
+	}
+}
+
+func TestGetLoginUserIsCached(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ClearUserCache()
+	t.Cleanup(ClearUserCache)
+	service := NewUserService()
+	ctx := context.Background()
+
+	user, suspension, err := service.GetLoginUser(ctx, "auth0", "auth0|max", "max@example.com", "Max")
+	if err != nil || suspension != nil {
+		t.Fatalf("GetLoginUser returned suspension %v, error %v", suspension, err)
+	}
+
+	// Changes behind the service's back are served stale...
+	db.Model(&models.User{}).Where("id = ?", user.ID).Update("bio", "Juggler")
+	cached, _, err := service.GetLoginUser(ctx, "auth0", "auth0|max", "max@example.com", "Max")
+	if err != nil {
+		t.Fatalf("GetLoginUser returned error: %v", err)
+	}
+	if cached.Bio != "" {
+		t.Errorf("Expected the cached user, got bio '%s'", cached.Bio)
+	}
+
+	// ...until the user is forgotten, as every service that changes them does
+	if _, err := service.SuspendUser(ctx, user.ID, user.ID, "Spam", nil); err != nil {
+		t.Fatalf("SuspendUser returned error: %v", err)
+	}
+	fresh, suspension, err := service.GetLoginUser(ctx, "auth0", "auth0|max", "max@example.com", "Max")
+	if err != nil {
+		t.Fatalf("GetLoginUser returned error: %v", err)
+	}
+	if fresh.Bio != "Juggler" || suspension == nil {
+		t.Errorf("Expected the changed user and their suspension, got bio '%s' and suspension %v", fresh.Bio, suspension)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"skillswap/internal/database"
+	"skillswap/internal/models"
+	"skillswap/internal/moderation"
+	"skillswap/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 30
+	// UsernameHoldPeriod is how long a username someone gave up stays theirs:
+	// links to it redirect to them and nobody else can claim it
+	UsernameHoldPeriod = 90 * 24 * time.Hour
+
+	// maxUsernameAttempts caps how often signup suggests another username
+	// after losing one to a concurrent signup
+	maxUsernameAttempts = 5
+)
+
+var (
+	// ErrUsernameInvalid is returned for a username that breaks the format rules
+	ErrUsernameInvalid = fmt.Errorf("must be %d to %d letters, digits or underscores, starting with a letter", UsernameMinLength, UsernameMaxLength)
+	// ErrUsernameReserved is returned for a username kept for the site itself
+	ErrUsernameReserved = errors.New("is reserved")
+	// ErrUsernameNotAllowed is returned for a username the moderation pre-screen flags
+	ErrUsernameNotAllowed = errors.New("is not allowed")
+	// ErrUsernameTaken is returned for a username someone else has, or recently gave up
+	ErrUsernameTaken = repository.ErrUsernameTaken
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// reservedUsernames can't be taken by anyone, in any case, so they can't be
+// used to pass as staff or collide with routes
+var reservedUsernames = map[string]bool{
+	"about": true, "admin": true, "administrator": true, "anonymous": true, "api": true,
+	"deleted": true, "help": true, "login": true, "logout": true, "me": true,
+	"mod": true, "moderator": true, "null": true, "official": true, "privacy": true,
+	"root": true, "security": true, "settings": true, "signup": true, "skillswap": true,
+	"staff": true, "support": true, "system": true, "terms": true, "undefined": true,
+}
+
+// ValidateUsername checks a username against the format rules, the reserved
+// list and the moderation pre-screen. It doesn't check whether the name is
+// free. A flagged username is refused outright rather than held, since it
+// would show in links and mentions until a moderator got to it.
+func ValidateUsername(username string) error {
+	if len(username) < UsernameMinLength || len(username) > UsernameMaxLength || !usernamePattern.MatchString(username) {
+		return ErrUsernameInvalid
+	}
+	if reservedUsernames[strings.ToLower(username)] {
+		return ErrUsernameReserved
+	}
+	if moderation.Screen(username) != "" {
+		return ErrUsernameNotAllowed
+	}
+	return nil
+}
+
+type UsernameService struct{}
+
+func NewUsernameService() *UsernameService {
+	return &UsernameService{}
+}
+
+// CheckAvailability reports whether userID could change to username
+func (s *UsernameService) CheckAvailability(ctx context.Context, userID, username string) (*models.UsernameAvailability, error) {
+	availability := &models.UsernameAvailability{Username: username, Available: true}
+	if err := ValidateUsername(username); err != nil {
+		availability.Available, availability.Reason = false, "Username "+err.Error()
+		return availability, nil
+	}
+
+	userRepo := repository.NewUserRepository(database.DB.WithContext(ctx))
+	taken, err := userRepo.UsernameTaken(userID, username, heldSince())
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		availability.Available, availability.Reason = false, "Username "+ErrUsernameTaken.Error()
+	}
+	return availability, nil
+}
+
+// ChangeUsername renames a user after checking the format rules. The old name
+// stays in their history, so links to it redirect for UsernameHoldPeriod.
+func (s *UsernameService) ChangeUsername(ctx context.Context, userID, username string) error {
+	if err := ValidateUsername(username); err != nil {
+		return err
+	}
+	if err := repository.NewUserRepository(database.DB.WithContext(ctx)).ChangeUsername(userID, username, heldSince()); err != nil {
+		return err
+	}
+	ForgetUser(userID)
+	return nil
+}
+
+// ResolveUsername finds the user in the public directory a username belongs
+// to, ignoring case. moved is true when it was one of their old usernames,
+// given up within UsernameHoldPeriod.
+func (s *UsernameService) ResolveUsername(ctx context.Context, username string) (user *models.User, moved bool, err error) {
+	userRepo := repository.NewUserRepository(database.DB.WithContext(ctx))
+
+	user, err = userRepo.GetPublicUserByUsername(username)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, false, err
+	}
+	user, err = userRepo.GetPublicUserByOldUsername(username, heldSince())
+	return user, err == nil, err
+}
+
+// SuggestUsername picks a free username for a new user from their email, e.g.
+// "dana.smith@example.com" becomes "danasmith", or "danasmith1" if that's
+// taken. Another signup can take it first; the insert then fails with
+// gorm.ErrDuplicatedKey and the caller asks again.
+func (s *UsernameService) SuggestUsername(db *gorm.DB, email string) (string, error) {
+	base := usernameBase(email)
+
+	taken, err := repository.NewUserRepository(db).TakenUsernames(base, heldSince())
+	if err != nil {
+		return "", err
+	}
+	inUse := make(map[string]bool, len(taken))
+	for _, name := range taken {
+		inUse[name] = true
+	}
+
+	if !inUse[base] && !reservedUsernames[base] {
+		return base, nil
+	}
+	for counter := 1; ; counter++ {
+		candidate := fmt.Sprintf("%s%d", base, counter)
+		if !inUse[candidate] {
+			return candidate, nil
+		}
+	}
+}
+
+// usernameBase turns an email's local part into a valid username, leaving
+// room for a numeric suffix
+func usernameBase(email string) string {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+
+	var base strings.Builder
+	for _, r := range local {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			base.WriteRune(r)
+		}
+	}
+	name := base.String()
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "user" + name
+	}
+	if len(name) > UsernameMaxLength-6 {
+		name = name[:UsernameMaxLength-6]
+	}
+	for len(name) < UsernameMinLength {
+		name += "_"
+	}
+	return name
+}
+
+// heldSince is the oldest change whose old username is still held
+func heldSince() time.Time {
+	return time.Now().Add(-UsernameHoldPeriod)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"skillswap/internal/models"
+	"skillswap/internal/moderation"
+	"skillswap/internal/testutil"
+)
+
+func TestValidateUsername(t *testing.T) {
+	moderation.SetScreener(moderation.NewWordList("casino"))
+	t.Cleanup(func() { moderation.SetScreener(moderation.Chain{}) })
+
+	tests := []struct {
+		username string
+		want     error
+	}{
+		{"dana", nil},
+		{"Dana_Smith2", nil},
+		{"ab", ErrUsernameInvalid},
+		{"abcdefghijklmnopqrstuvwxyz12345", ErrUsernameInvalid},
+		{"2dana", ErrUsernameInvalid},
+		{"_dana", ErrUsernameInvalid},
+		{"dana.smith", ErrUsernameInvalid},
+		{"dana smith", ErrUsernameInvalid},
+		{"dañа", ErrUsernameInvalid},
+		{"admin", ErrUsernameReserved},
+		{"Support", ErrUsernameReserved},
+		{"casino_king", ErrUsernameNotAllowed},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateUsername(tt.username); !errors.Is(err, tt.want) {
+			t.Errorf("ValidateUsername(%q) = %v, want %v", tt.username, err, tt.want)
+		}
+	}
+}
+
+func TestUsernameBase(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"dana.smith@example.com", "danasmith"},
+		{"Sam+SkillSwap@example.com", "samskillswap"},
+		{"42@example.com", "user42"},
+		{"jo@example.com", "jo_"},
+		{"@example.com", "user"},
+		{"a.very.long.local.part.indeed@example.com", "averylonglocalpartindeed"},
+	}
+
+	for _, tt := range tests {
+		got := usernameBase(tt.email)
+		if got != tt.want {
+			t.Errorf("usernameBase(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+		if err := ValidateUsername(got); err != nil && !errors.Is(err, ErrUsernameReserved) {
+			t.Errorf("usernameBase(%q) = %q, which is invalid: %v", tt.email, got, err)
+		}
+	}
+}
+
+func TestChangeUsernameRedirectsOldName(t *testing.T) {
+	testutil.NewTestDB(t)
+	ctx := context.Background()
+	users := NewUserService()
+	usernames := NewUsernameService()
+
+	dana, err := users.GetOrCreateUser(ctx, "auth0", "auth0|dana", "dana@example.com", "Dana")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	eli, err := users.GetOrCreateUser(ctx, "auth0", "auth0|eli", "eli@example.com", "Eli")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+
+	if err := usernames.ChangeUsername(ctx, dana.ID, "DanaTeaches"); err != nil {
+		t.Fatalf("ChangeUsername returned error: %v", err)
+	}
+
+	// The new name resolves in any case, the old one redirects
+	user, moved, err := usernames.ResolveUsername(ctx, "danateaches")
+	if err != nil || moved || user.ID != dana.ID {
+		t.Errorf("Expected danateaches to resolve to Dana, got %v, moved %v, err %v", user, moved, err)
+	}
+	user, moved, err = usernames.ResolveUsername(ctx, "dana")
+	if err != nil || !moved || user.Username != "DanaTeaches" {
+		t.Errorf("Expected dana to redirect to DanaTeaches, got %v, moved %v, err %v", user, moved, err)
+	}
+
+	// The old name is held for Dana, in any case
+	if err := usernames.ChangeUsername(ctx, eli.ID, "Dana"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("Expected ErrUsernameTaken for a held username, got %v", err)
+	}
+	if err := usernames.ChangeUsername(ctx, eli.ID, "danaTEACHES"); !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("Expected ErrUsernameTaken for a username in use, got %v", err)
+	}
+	availability, err := usernames.CheckAvailability(ctx, eli.ID, "dana")
+	if err != nil {
+		t.Fatalf("CheckAvailability returned error: %v", err)
+	}
+	if availability.Available {
+		t.Error("Expected a held username to be unavailable")
+	}
+
+	// ...but Dana can take it back
+	availability, err = usernames.CheckAvailability(ctx, dana.ID, "dana")
+	if err != nil {
+		t.Fatalf("CheckAvailability returned error: %v", err)
+	}
+	if !availability.Available {
+		t.Errorf("Expected Dana to be able to take back their old username, got %q", availability.Reason)
+	}
+
+	// Nor does signup suggest a held name
+	newcomer, err := users.GetOrCreateUser(ctx, "auth0", "auth0|dana2", "dana@example.org", "")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	if newcomer.Username != "dana1" {
+		t.Errorf("Expected username 'dana1', got '%s'", newcomer.Username)
+	}
+}
+
+func TestChangeUsernameCaseOnly(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	ctx := context.Background()
+
+	sam, err := NewUserService().GetOrCreateUser(ctx, "auth0", "auth0|sam", "sam@example.com", "Sam")
+	if err != nil {
+		t.Fatalf("GetOrCreateUser returned error: %v", err)
+	}
+	if err := NewUsernameService().ChangeUsername(ctx, sam.ID, "Sam"); err != nil {
+		t.Fatalf("ChangeUsername returned error: %v", err)
+	}
+
+	var changes int64
+	db.Model(&models.UsernameChange{}).Where("user_id = ?", sam.ID).Count(&changes)
+	if changes != 0 {
+		t.Errorf("Expected a change of case to leave no history, got %d changes", changes)
+	}
+}
+
+func TestUpdateProfileWithTakenUsernameChangesNothing(t *testing.T) {
+	testutil.NewTestDB(t)
+	ctx := context.Background()
+	users := NewUserService()
+
+	dana, _ := users.GetOrCreateUser(ctx, "auth0", "auth0|dana", "dana@example.com", "Dana")
+	eli, _ := users.GetOrCreateUser(ctx, "auth0", "auth0|eli", "eli@example.com", "Eli")
+
+	updateReq := models.UpdateUserRequest{Username: dana.Username, Bio: "I teach chess"}
+	if _, err := users.UpdateProfile(ctx, eli.ID, &updateReq); !errors.Is(err, ErrUsernameTaken) {
+		t.Fatalf("Expected ErrUsernameTaken, got %v", err)
+	}
+
+	updated, _ := users.GetUserByID(ctx, eli.ID)
+	if updated.Username != eli.Username || updated.Bio != "" {
+		t.Errorf("Expected the profile to be left as it was, got %q, %q", updated.Username, updated.Bio)
+	}
+}
@@ -0,0 +1,174 @@
+// Package testutil provides an integration test harness: a throwaway Postgres
+// server, an isolated schema per test and locally signed JWTs.
+package testutil
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"skillswap/internal/database"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestDatabaseURLEnv points the harness at an existing Postgres server instead
+// of starting an embedded one (useful in CI where a service container exists)
+const TestDatabaseURLEnv = "TEST_DATABASE_URL"
+
+var (
+	baseDSN    string
+	startErr   error
+	adminDB    *gorm.DB
+	embeddedPG *embeddedpostgres.EmbeddedPostgres
+)
+
+// RunWithPostgres starts a throwaway Postgres, runs the package's tests and
+// tears the server down again. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) { os.Exit(testutil.RunWithPostgres(m)) }
+//
+// If no server can be started, tests calling NewTestDB are skipped.
+func RunWithPostgres(m *testing.M) int {
+	if err := startPostgres(); err != nil {
+		startErr = err
+		log.Printf("Integration tests will be skipped: %v", err)
+	}
+	defer stopPostgres()
+
+	return m.Run()
+}
+
+func startPostgres() error {
+	if dsn := os.Getenv(TestDatabaseURLEnv); dsn != "" {
+		baseDSN = dsn
+		return openAdminDB()
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+
+	runtimeDir, err := os.MkdirTemp("", "skillswap-pg-")
+	if err != nil {
+		return err
+	}
+
+	embeddedPG = embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(uint32(port)).
+		Database("skillswap_test").
+		RuntimePath(runtimeDir).
+		DataPath(filepath.Join(runtimeDir, "data")).
+		Logger(nil))
+	if err := embeddedPG.Start(); err != nil {
+		embeddedPG = nil
+		os.RemoveAll(runtimeDir)
+		return fmt.Errorf("failed to start embedded postgres: %w", err)
+	}
+
+	baseDSN = fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=skillswap_test sslmode=disable TimeZone=UTC", port)
+	return openAdminDB()
+}
+
+func stopPostgres() {
+	if adminDB != nil {
+		if sqlDB, err := adminDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	if embeddedPG != nil {
+		if err := embeddedPG.Stop(); err != nil {
+			log.Printf("Failed to stop embedded postgres: %v", err)
+		}
+	}
+}
+
+func openAdminDB() error {
+	var err error
+	adminDB, err = gorm.Open(postgres.Open(baseDSN), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to test database: %w", err)
+	}
+	return nil
+}
+
+// NewTestDB gives the calling test its own freshly migrated schema and points
+// database.DB at it. The schema is dropped and database.DB restored when the
+// test finishes, so tests using it must not run in parallel.
+func NewTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	if adminDB == nil {
+		if startErr != nil {
+			t.Skipf("postgres not available: %v", startErr)
+		}
+		t.Skip("postgres not available: call testutil.RunWithPostgres from TestMain")
+	}
+
+	schema := "test_" + randomHex(6)
+	if err := adminDB.Exec(fmt.Sprintf("CREATE SCHEMA %q", schema)).Error; err != nil {
+		t.Fatalf("failed to create schema %s: %v", schema, err)
+	}
+
+	previous := database.DB
+	if err := database.ConnectDSN(withSearchPath(baseDSN, schema)); err != nil {
+		t.Fatalf("failed to connect to schema %s: %v", schema, err)
+	}
+	db := database.DB
+
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = previous
+		if err := adminDB.Exec(fmt.Sprintf("DROP SCHEMA %q CASCADE", schema)).Error; err != nil {
+			t.Logf("failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	if err := database.Migrate(); err != nil {
+		t.Fatalf("failed to migrate schema %s: %v", schema, err)
+	}
+
+	return db
+}
+
+// withSearchPath scopes a DSN (URL or key=value form) to a single schema
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
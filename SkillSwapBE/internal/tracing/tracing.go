@@ -0,0 +1,139 @@
+// Package tracing sets up OpenTelemetry tracing and wraps HTTP middleware and
+// handlers in spans. Database queries get spans from the GORM plugin, as long
+// as they run with the request context (db.WithContext(ctx)).
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// ServiceName identifies this API in traces unless OTEL_SERVICE_NAME is set
+const ServiceName = "skillswap-api"
+
+const instrumentationName = "skillswap/internal/tracing"
+
+// Setup installs the global tracer provider for exporter: "otlp" (configured
+// by the standard OTEL_EXPORTER_OTLP_* variables), "stdout", or "none"/"" to
+// turn tracing off. Incoming traceparent headers are honoured either way.
+// The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(exporter) {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		spanExporter, err = otlptracehttp.New(ctx)
+	case "stdout", "console":
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected otlp, stdout or none", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName())))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func serviceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+	return ServiceName
+}
+
+// InstrumentDB adds a span for every query run through db
+func InstrumentDB(db *gorm.DB) error {
+	return db.Use(gormtracing.NewPlugin(gormtracing.WithoutMetrics()))
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// middlewareSpans is carried on the context between a middleware's span
+// starting and the middleware handing over to the next handler
+type middlewareSpans struct {
+	own    trace.Span
+	parent trace.Span
+}
+
+type middlewareSpansKey struct{}
+
+// Middleware wraps mw so that the time spent in it, up to the point it hands
+// over to the next handler, is recorded as its own span
+func Middleware(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		handOver := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if spans, ok := ctx.Value(middlewareSpansKey{}).(middlewareSpans); ok {
+				spans.own.End()
+				// Later spans belong to the request, not to this middleware
+				ctx = trace.ContextWithSpan(ctx, spans.parent)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+		wrapped := mw(handOver)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parent := trace.SpanFromContext(r.Context())
+			ctx, span := tracer().Start(r.Context(), "middleware "+name)
+			ctx = context.WithValue(ctx, middlewareSpansKey{}, middlewareSpans{own: span, parent: parent})
+
+			wrapped.ServeHTTP(w, r.WithContext(ctx))
+			span.End() // No-op unless the middleware stopped the request itself
+		})
+	}
+}
+
+// Handler records the matched route's handler as its own span. Use it as the
+// last middleware on a router so the span covers only the handler.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				name = template
+			}
+		}
+
+		ctx, span := tracer().Start(r.Context(), "handler "+r.Method+" "+name,
+			trace.WithAttributes(attribute.String("http.route", name)))
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestSpansNestUnderIncomingTrace(t *testing.T) {
+	recorder := recordSpans(t)
+
+	passThrough := func(next http.Handler) http.Handler { return next }
+	router := mux.NewRouter()
+	router.Use(otelmux.Middleware(ServiceName))
+	router.Use(Middleware("first", passThrough))
+	router.Use(Middleware("second", passThrough))
+	router.Use(Handler)
+	router.HandleFunc("/skills/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods("GET")
+
+	req := httptest.NewRequest("GET", "/skills/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	request, ok := spans["/skills/{id}"]
+	if !ok {
+		t.Fatalf("Expected a request span, got %v", spans)
+	}
+	if got := request.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the incoming trace ID to be kept, got %s", got)
+	}
+	if got := request.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("Expected the request span to continue the caller's span, got parent %s", got)
+	}
+
+	// Middleware and handler spans are siblings under the request span
+	for _, name := range []string{"middleware first", "middleware second", "handler GET /skills/{id}"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("Expected a %q span", name)
+			continue
+		}
+		if span.Parent().SpanID() != request.SpanContext().SpanID() {
+			t.Errorf("Expected %q to be a child of the request span", name)
+		}
+	}
+}
+
+func TestMiddlewareSpanEndsWhenRequestIsStopped(t *testing.T) {
+	recorder := recordSpans(t)
+
+	reject := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		})
+	}
+	handlerCalled := false
+	handler := Middleware("reject", reject)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalled = true
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if handlerCalled {
+		t.Error("Expected the request to stop at the middleware")
+	}
+	if ended := recorder.Ended(); len(ended) != 1 || ended[0].Name() != "middleware reject" {
+		t.Errorf("Expected only the middleware span to end, got %d spans", len(ended))
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(t.Context(), "zipkin"); err == nil {
+		t.Error("Expected an error for an unknown exporter")
+	}
+}
@@ -0,0 +1,93 @@
+// Package validation enforces the `binding` struct tags on request models,
+// e.g. `binding:"required,min=15"`, using go-playground/validator rules.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"skillswap/internal/apierror"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName("binding")
+
+	// Report fields by the name clients send, not the Go field name
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Struct checks s against its binding tags and returns the invalid fields,
+// or nil if s is valid
+func Struct(s interface{}) []apierror.FieldError {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		// Only returned for a nil or non-struct argument, which is a programming error
+		panic(fmt.Sprintf("validation: cannot validate %T: %v", s, err))
+	}
+
+	fields := make([]apierror.FieldError, 0, len(invalid))
+	for _, fieldErr := range invalid {
+		fields = append(fields, apierror.FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: message(fieldErr),
+		})
+	}
+	return fields
+}
+
+// fieldPath drops the top-level struct name from the namespace, so a nested
+// field reads "address.city" rather than "CreateRequest.address.city"
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+	return path
+}
+
+func message(fieldErr validator.FieldError) string {
+	isText := fieldErr.Kind() == reflect.String
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isText {
+			return fmt.Sprintf("must be at least %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "max":
+		if isText {
+			return fmt.Sprintf("must be at most %s characters", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "url":
+		return "must be a URL"
+	case "email":
+		return "must be an email address"
+	}
+	return fmt.Sprintf("failed the %s rule", fieldErr.Tag())
+}
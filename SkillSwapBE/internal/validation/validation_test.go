@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"testing"
+
+	"skillswap/internal/models"
+)
+
+func TestStructEnforcesBindingTags(t *testing.T) {
+	fields := Struct(&models.CreateSkillRequest{Title: "Pottery", Price: 20, Duration: 10})
+
+	got := map[string]string{}
+	for _, field := range fields {
+		got[field.Field] = field.Rule
+	}
+	want := map[string]string{"category": "required", "duration": "min"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d invalid fields, got %+v", len(want), fields)
+	}
+	for field, rule := range want {
+		if got[field] != rule {
+			t.Errorf("Expected %s to fail %q, got %q", field, rule, got[field])
+		}
+	}
+}
+
+func TestStructMessages(t *testing.T) {
+	fields := Struct(&models.CreateReviewRequest{RevieweeID: "user-1", Rating: 6})
+	if len(fields) != 1 || fields[0].Field != "rating" || fields[0].Message != "must be at most 5" {
+		t.Errorf("Expected rating to be at most 5, got %+v", fields)
+	}
+
+	fields = Struct(&models.ResolveReportRequest{Status: "ignored"})
+	if len(fields) != 1 || fields[0].Message != "must be one of: actioned, dismissed" {
+		t.Errorf("Expected status to list the allowed values, got %+v", fields)
+	}
+}
+
+func TestStructAllowsOmittedOptionalFields(t *testing.T) {
+	if fields := Struct(&models.UpdateSkillRequest{Title: "Wheel throwing"}); fields != nil {
+		t.Errorf("Expected a partial update to be valid, got %+v", fields)
+	}
+	if fields := Struct(&models.UpdateUserRequest{Bio: "Potter"}); fields != nil {
+		t.Errorf("Expected a partial profile update to be valid, got %+v", fields)
+	}
+}
@@ -0,0 +1,35 @@
+// Package version reports the build's version and git commit. Both are set at
+// build time with -ldflags, e.g.
+//
+//	go build -ldflags "-X skillswap/internal/version.Version=1.2.0 -X skillswap/internal/version.Commit=$(git rev-parse HEAD)"
+package version
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// Version is the release version of this build
+var Version = "dev"
+
+// Commit is the git SHA this build was made from. When it isn't set at build
+// time it falls back to the commit Go stamped into the binary, then to the
+// slug commit Heroku exposes to dynos.
+var Commit = ""
+
+func init() {
+	if Commit != "" {
+		return
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				Commit = setting.Value
+				return
+			}
+		}
+	}
+	if Commit = os.Getenv("HEROKU_SLUG_COMMIT"); Commit == "" {
+		Commit = "unknown"
+	}
+}
@@ -70,7 +70,11 @@ const Profile: React.FC = () => {
     );
   }
 
-  const { user, skills, reviews, review_summary } = profile;
+  const { user, review_summary } = profile;
+  const skills = profile.skills.data;
+  const reviews = profile.reviews.data;
+  // Only the first page of skills is loaded, so mark the count when there are more
+  const skillCount = `${skills.length}${profile.skills.next_cursor ? '+' : ''}`;
 
   return (
     <div className="min-h-screen bg-gray-50">
@@ -125,7 +129,7 @@ const Profile: React.FC = () => {
             <div className="text-sm text-gray-600">Points</div>
           </div>
           <div className="bg-white p-4 rounded-lg shadow-md text-center">
-            <div className="text-2xl font-bold text-green-600">{skills.length}</div>
+            <div className="text-2xl font-bold text-green-600">{skillCount}</div>
             <div className="text-sm text-gray-600">Skills</div>
           </div>
           <div className="bg-white p-4 rounded-lg shadow-md text-center">
@@ -143,7 +147,7 @@ const Profile: React.FC = () => {
         {/* Skills Section */}
         <div className="bg-white rounded-lg shadow-md p-6">
           <h2 className="text-xl font-semibold text-gray-900 mb-4">
-            Skills Offered ({skills.length})
+            Skills Offered ({skillCount})
           </h2>
           
           {skills.length > 0 ? (
@@ -26,11 +26,26 @@ export interface ReviewSummary {
   rating_breakdown: { [key: number]: number };
 }
 
+// Page is the envelope every list endpoint responds with. Pass next_cursor
+// back as the cursor parameter for the next page; it is null on the last one.
+export interface Page<T> {
+  data: T[];
+  next_cursor: string | null;
+}
+
+export interface EarnedBadge {
+  id: string;
+  name: string;
+  description: string;
+  awarded_at: string;
+}
+
 export interface ProfileResponse {
   user: UserProfile;
-  skills: Skill[];
-  reviews: Review[];
+  skills: Page<Skill>;
+  reviews: Page<Review>;
   review_summary: ReviewSummary;
+  badges: EarnedBadge[];
 }
 
 export interface Booking {
@@ -55,19 +70,23 @@ class ApiService {
   }
 
   // Public endpoints
-  async getPublicSkills(): Promise<Skill[]> {
-    const response = await fetch(`${API_BASE_URL}/public/skills`);
+  async getPublicSkills(cursor?: string): Promise<Page<Skill>> {
+    const searchParams = new URLSearchParams();
+    if (cursor) searchParams.append('cursor', cursor);
+
+    const response = await fetch(`${API_BASE_URL}/public/skills?${searchParams}`);
     if (!response.ok) {
       throw new Error('Failed to fetch skills');
     }
     return response.json();
   }
 
-  async searchSkills(params: { category?: string; location?: string; query?: string }): Promise<Skill[]> {
+  async searchSkills(params: { category?: string; location?: string; query?: string; cursor?: string }): Promise<Page<Skill>> {
     const searchParams = new URLSearchParams();
     if (params.category) searchParams.append('category', params.category);
     if (params.location) searchParams.append('location', params.location);
     if (params.query) searchParams.append('query', params.query);
+    if (params.cursor) searchParams.append('cursor', params.cursor);
 
     const response = await fetch(`${API_BASE_URL}/public/skills/search?${searchParams}`);
     if (!response.ok) {
@@ -102,8 +121,11 @@ class ApiService {
     return response.json();
   }
 
-  async getMySkills(token: string): Promise<Skill[]> {
-    const response = await fetch(`${API_BASE_URL}/protected/my-skills`, {
+  async getMySkills(token: string, cursor?: string): Promise<Page<Skill>> {
+    const searchParams = new URLSearchParams();
+    if (cursor) searchParams.append('cursor', cursor);
+
+    const response = await fetch(`${API_BASE_URL}/protected/my-skills?${searchParams}`, {
       headers: this.getAuthHeaders(token),
     });
     
@@ -13,23 +13,22 @@ export interface User {
 
 export interface UserProfile {
   id: string;
-  auth0_id: string;
   username: string;
-  email: string;
+  email?: string; // Only on your own account
+  role?: string; // Left out of the public directory
   full_name: string;
   name: string;
   location: string;
   avatar?: string;
+  avatar_thumbnail?: string; // Only for uploaded avatars
   bio?: string;
   rating: number;
   join_date: string;
   points: number;
   rank: string;
   review_count: number;
-  skills: Skill[];
-  reviews: Review[];
   created_at: string;
-  updated_at: string;
+  updated_at?: string; // Only on your own account
 }
 
 export interface Review {
@@ -48,6 +47,7 @@ export interface Skill {
   title: string;
   description: string;
   category: string;
+  category_id?: string;
   user_id: string;
   user: User;
   price: number;
@@ -55,12 +55,24 @@ export interface Skill {
   location: string;
   is_active: boolean;
   level?: string;
+  tags: string[];
   rating?: number;
   review_count?: number;
+  images?: SkillImage[];
   created_at: string;
   updated_at: string;
 }
 
+export interface SkillImage {
+  id: string;
+  skill_id: string;
+  url: string;
+  thumbnail_url: string;
+  width: number;
+  height: number;
+  created_at: string;
+}
+
 export interface AuthContextType {
   isAuthenticated: boolean;
   user: any;